@@ -0,0 +1,280 @@
+// Package alphabeta provides iterative-deepening alpha-beta (negamax) search
+// for gomoku games, with moves ordered and positions evaluated by
+// the patterns of stones.
+package alphabeta
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/eval"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// Score of a win. A win at the n-th ply from the root scores WinScore - n,
+// so that faster wins and slower losses are preferred.
+const WinScore int = eval.FiveScore * 100
+
+const infScore int = math.MaxInt32
+
+// Maximum distance from the stones to the moves searched.
+const validDistThold int = 2
+
+// Number of nodes between two checks of the deadline and the context.
+const checkInterval uint64 = 1024
+
+var errAborted = errors.New("search is aborted")
+
+type Result struct {
+	Pos board.Position
+	// Score of Pos for the player to move, by the last completed depth.
+	Score int
+	// Last completed depth, in plies. 0 if no depth is completed,
+	// and Pos is the first move by the pattern scores.
+	Depth   int
+	NumNode uint64
+}
+
+// Return true if the search proved a win or a loss.
+func (r *Result) IsDecided() bool {
+	return r != nil && isDecided(r.Score)
+}
+
+// Search the position on b for the stone of the specified step, deepening
+// until Settings.MaxDepth, Settings.TimeLimit, or ctx is done.
+// b is not modified.
+// If settings is nil, default settings are used.
+// If ctx is done before depth 1 is completed, ctx.Err() is returned.
+func Search(ctx context.Context, rule rules.Rule, b *board.Board, step uint,
+	settings *Settings) (*Result, error) {
+	return search(ctx, rule, b, step, settings, false)
+}
+
+// Same as Search, but return the move whose score is the closest to 0,
+// i.e. the one keeping the position the most balanced, for the openings
+// where the opponent chooses color afterwards.
+// Each move at the root is searched with the full window, so it's slower.
+func SearchBalanced(ctx context.Context, rule rules.Rule, b *board.Board,
+	step uint, settings *Settings) (*Result, error) {
+	return search(ctx, rule, b, step, settings, true)
+}
+
+type searcher struct {
+	ctx      context.Context
+	rule     rules.Rule
+	settings *Settings
+	b        *board.Board
+	deadline time.Time
+	numNode  uint64
+}
+
+func search(ctx context.Context, rule rules.Rule, b *board.Board, step uint,
+	settings *Settings, isBalanced bool) (*Result, error) {
+	if b == nil {
+		return nil, errors.New("board is nil")
+	}
+	if step == 0 {
+		return nil, errors.New("step is 0")
+	}
+	if settings == nil {
+		settings = NewSettings()
+	}
+	s := &searcher{
+		ctx:      ctx,
+		rule:     rule,
+		settings: settings,
+		b:        b.Copy(),
+	}
+	if settings.TimeLimit > 0 {
+		s.deadline = time.Now().Add(settings.TimeLimit)
+	}
+	moves := s.orderedMoves(step)
+	if len(moves) == 0 {
+		return nil, errors.New("cannot find a position to place stone")
+	}
+	r := &Result{Pos: moves[0]}
+	maxDepth := settings.MaxDepth
+	if maxDepth < 1 {
+		maxDepth = 1
+	}
+	for depth := 1; depth <= maxDepth; depth++ {
+		pos, score, err := s.searchRoot(moves, step, depth, isBalanced)
+		if err == errAborted {
+			break
+		}
+		r.Pos, r.Score, r.Depth = pos, score, depth
+		// Search the best move first at the next depth.
+		for i := range moves {
+			if moves[i] == pos {
+				copy(moves[1:i+1], moves[:i])
+				moves[0] = pos
+				break
+			}
+		}
+		if isDecided(score) && !isBalanced {
+			break
+		}
+	}
+	r.NumNode = s.numNode
+	if r.Depth == 0 && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return r, nil
+}
+
+func (s *searcher) searchRoot(moves []board.Position, step uint, depth int,
+	isBalanced bool) (best board.Position, bestScore int, err error) {
+	best = board.InvalidPosition
+	alpha := -infScore
+	for _, pos := range moves {
+		if s.isAborted() {
+			return board.InvalidPosition, 0, errAborted
+		}
+		var score int
+		if isBalanced {
+			score, err = s.scoreMove(pos, step, depth, -infScore, infScore, 1)
+		} else {
+			score, err = s.scoreMove(pos, step, depth, alpha, infScore, 1)
+		}
+		if err != nil {
+			return
+		}
+		if best == board.InvalidPosition ||
+			(isBalanced && abs(score) < abs(bestScore)) ||
+			(!isBalanced && score > bestScore) {
+			best, bestScore = pos, score
+			if score > alpha {
+				alpha = score
+			}
+		}
+	}
+	return
+}
+
+// Place the stone of step at pos, search depth plies including it, and
+// return the score for the player who placed it.
+func (s *searcher) scoreMove(pos board.Position, step uint, depth int,
+	alpha, beta int, ply int) (int, error) {
+	s.numNode++
+	if s.numNode%checkInterval == 0 && s.isAborted() {
+		return 0, errAborted
+	}
+	piece := board.PieceOfStep(step)
+	s.b.Set(pos, piece)
+	defer s.b.Set(pos, 0)
+	if rules.CheckOutcome(s.rule, s.b, pos) == piece {
+		return WinScore - ply, nil
+	}
+	if depth <= 1 {
+		return eval.Evaluate(s.rule, s.b, piece), nil
+	}
+	score, err := s.negamax(step+1, depth-1, -beta, -alpha, ply+1)
+	return -score, err
+}
+
+// Return the score for the player to place the stone of step.
+func (s *searcher) negamax(step uint, depth int, alpha, beta int, ply int) (
+	int, error) {
+	moves := s.orderedMoves(step)
+	if len(moves) == 0 {
+		// Draw.
+		return 0, nil
+	}
+	best := -infScore
+	for _, pos := range moves {
+		score, err := s.scoreMove(pos, step, depth, alpha, beta, ply)
+		if err != nil {
+			return 0, err
+		}
+		if score > best {
+			best = score
+			if score > alpha {
+				alpha = score
+				if alpha >= beta {
+					break
+				}
+			}
+		}
+	}
+	return best, nil
+}
+
+// Return the valid positions for the stone of step, in descending order of
+// the pattern scores of both players on them, up to Settings.NumCandidate.
+// If the player can win, only the winning positions are returned,
+// or if the opponent can, only the positions blocking it.
+func (s *searcher) orderedMoves(step uint) []board.Position {
+	vps := rules.GetValidPositions(s.rule, s.b, step, validDistThold)
+	piece := board.PieceOfStep(step)
+	opponent := board.PieceOfStep(step + 1)
+	scores := make([]int, len(vps))
+	var wins, blocks []board.Position
+	for i, pos := range vps {
+		s.b.Set(pos, piece)
+		isWin := rules.CheckOutcome(s.rule, s.b, pos) == piece
+		s.b.Set(pos, opponent)
+		isLoss := rules.CheckOutcome(s.rule, s.b, pos) == opponent
+		s.b.Set(pos, 0)
+		if isWin {
+			wins = append(wins, pos)
+		} else if isLoss {
+			blocks = append(blocks, pos)
+		}
+		scores[i] = eval.ScoreMove(s.rule, s.b, pos, piece) +
+			eval.ScoreMove(s.rule, s.b, pos, opponent)
+	}
+	if len(wins) > 0 {
+		return wins
+	}
+	if len(blocks) > 0 {
+		return blocks
+	}
+	sort.Stable(&movesByScore{vps, scores})
+	if n := s.settings.NumCandidate; n > 0 && n < len(vps) {
+		vps = vps[:n]
+	}
+	return vps
+}
+
+func (s *searcher) isAborted() bool {
+	select {
+	case <-s.ctx.Done():
+		return true
+	default:
+	}
+	return !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
+func isDecided(score int) bool {
+	return abs(score) > WinScore/2
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Sort positions in descending order of scores.
+type movesByScore struct {
+	moves  []board.Position
+	scores []int
+}
+
+func (m *movesByScore) Len() int {
+	return len(m.moves)
+}
+
+func (m *movesByScore) Less(i, j int) bool {
+	return m.scores[i] > m.scores[j]
+}
+
+func (m *movesByScore) Swap(i, j int) {
+	m.moves[i], m.moves[j] = m.moves[j], m.moves[i]
+	m.scores[i], m.scores[j] = m.scores[j], m.scores[i]
+}
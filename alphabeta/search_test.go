@@ -0,0 +1,114 @@
+package alphabeta
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestSearch(t *testing.T) {
+	cases := []struct {
+		name         string
+		black, white []string
+		step         uint
+		want         []string
+	}{
+		{"make five", []string{"H8", "I8", "J8", "K8"},
+			[]string{"H9", "I9", "J9", "A1"}, 9, []string{"G8", "L8"}},
+		{"block four", []string{"H8", "I8", "J8", "G9"},
+			[]string{"H9", "I9", "J9", "K9"}, 9, []string{"L9"}},
+		{"make open four", []string{"H8", "I8", "J8"},
+			[]string{"H10", "O15", "A15"}, 7, []string{"G8", "K8"}},
+		// F3 makes an overline of White, which doesn't win.
+		{"block four, not overline",
+			[]string{"H8", "I8", "J8", "K8", "A15", "O15", "O1"},
+			[]string{"C3", "D3", "E3", "G3", "H3", "G8"}, 14,
+			[]string{"L8"}},
+	}
+	settings := NewSettings()
+	settings.TimeLimit = time.Second * 10
+	settings.MaxDepth = 4
+	for _, c := range cases {
+		b := newTestBoard(t, c.black, c.white)
+		r, err := Search(context.Background(), rules.StandardGomoku, b,
+			c.step, settings)
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		t.Logf("%s: %v, score: %d, depth: %d, nodes: %d", c.name, r.Pos,
+			r.Score, r.Depth, r.NumNode)
+		var ok bool
+		for _, s := range c.want {
+			ok = ok || r.Pos.String() == s
+		}
+		if !ok {
+			t.Errorf("%s: %v, want one of %v", c.name, r.Pos, c.want)
+		}
+		if b.NumStone() != len(c.black)+len(c.white) {
+			t.Errorf("%s: board is modified", c.name)
+		}
+	}
+}
+
+func TestSearchDecided(t *testing.T) {
+	// Black has an open three and is to move, so it wins by an open four.
+	b := newTestBoard(t, []string{"H8", "I8", "J8"},
+		[]string{"H10", "O15", "A15"})
+	settings := NewSettings()
+	settings.MaxDepth = 5
+	r, err := Search(context.Background(), rules.StandardGomoku, b, 7,
+		settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.IsDecided() || r.Score <= 0 {
+		t.Errorf("score: %d, want a win", r.Score)
+	}
+}
+
+func TestSearchBalanced(t *testing.T) {
+	b := newTestBoard(t, []string{"H8", "I9"}, []string{"H9"})
+	settings := NewSettings()
+	settings.MaxDepth = 2
+	r, err := SearchBalanced(context.Background(), rules.StandardGomoku, b,
+		4, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Depth != 2 || b.Get(r.Pos) != 0 {
+		t.Errorf("position: %v, depth: %d", r.Pos, r.Depth)
+	}
+}
+
+func TestSearchCanceled(t *testing.T) {
+	b := newTestBoard(t, []string{"H8"}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	settings := NewSettings()
+	settings.NumCandidate = 0
+	settings.MaxDepth = 20
+	_, err := Search(ctx, rules.StandardGomoku, b, 2, settings)
+	if err != context.Canceled {
+		t.Errorf("error: %v, want %v", err, context.Canceled)
+	}
+}
+
+func newTestBoard(tb testing.TB, black, white []string) *board.Board {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for i, stones := range [...][]string{black, white} {
+		for _, s := range stones {
+			p, err := board.ParsePosition(s)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			b.Set(p, board.Black+board.Piece(i))
+		}
+	}
+	return b
+}
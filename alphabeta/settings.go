@@ -0,0 +1,20 @@
+package alphabeta
+
+import "time"
+
+type Settings struct {
+	TimeLimit time.Duration `json:"time_limit,omitempty"`
+	// Maximum depth of the iterative deepening, in plies.
+	MaxDepth int `json:"max_depth,omitempty"`
+	// Maximum number of moves searched at each node, the ones with
+	// the highest pattern scores. Not positive to search all valid moves.
+	NumCandidate int `json:"num_candidate,omitempty"`
+}
+
+func NewSettings() *Settings {
+	return &Settings{
+		TimeLimit:    time.Second * 15,
+		MaxDepth:     10,
+		NumCandidate: 12,
+	}
+}
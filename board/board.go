@@ -0,0 +1,207 @@
+// Package board provides the board, positions and pieces of gomoku games.
+package board
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const numLines int = MaxBoardSize*2 - 1
+
+// Board of a game.
+// Pieces are stored in an array indexed by Position, so lookup is O(1).
+// Stones are also recorded as bit patterns along the lines (rows, columns,
+// diagonals and anti-diagonals), which are updated incrementally on each Set,
+// and used to check lines and neighborhoods fast.
+// Board has no pointers inside, so it can be copied cheaply by assignment.
+type Board struct {
+	size     int
+	numStone int
+	cells    [NumPosition + 1]Piece
+	// Indexed by color(0 for Black, 1 for White), line orientation
+	// (see orientationOf), and line number(see lineOf).
+	// Bit i stands for x = i, except for vertical lines, where it's y = i.
+	lines [2][4][numLines]uint32
+}
+
+func NewBoard(size int) (*Board, error) {
+	if size < MinBoardSize || size > MaxBoardSize {
+		return nil, NewBoardSizeOutOfRangeError(size)
+	}
+	return &Board{size: size}, nil
+}
+
+func (b *Board) Size() int {
+	return b.size
+}
+
+func (b *Board) NumStone() int {
+	return b.numStone
+}
+
+// Return InvalidPiece if pos is outside the board.
+func (b *Board) Get(pos Position) Piece {
+	if b == nil || !pos.IsOnBoard(b.size) {
+		return InvalidPiece
+	}
+	return b.cells[pos]
+}
+
+// Place piece at pos. Set piece to 0 to remove the stone at pos.
+// It panics if pos is outside the board or piece is invalid, as it's used
+// in the hot loops of searches.
+func (b *Board) Set(pos Position, piece Piece) {
+	if !pos.IsOnBoard(b.size) {
+		panic(fmt.Errorf("position %v is outside the board", pos))
+	}
+	if piece != 0 && piece != Black && piece != White {
+		panic(fmt.Errorf("piece(%v) is invalid", piece))
+	}
+	old := b.cells[pos]
+	if old == piece {
+		return
+	}
+	if old != 0 {
+		c := colorIndex(old)
+		for o := range b.lines[c] {
+			b.lines[c][o][lineOf(pos, o)] &^= 1 << bitOf(pos, o)
+		}
+		b.numStone--
+	}
+	if piece != 0 {
+		c := colorIndex(piece)
+		for o := range b.lines[c] {
+			b.lines[c][o][lineOf(pos, o)] |= 1 << bitOf(pos, o)
+		}
+		b.numStone++
+	}
+	b.cells[pos] = piece
+}
+
+func (b *Board) Copy() *Board {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
+
+func (b *Board) CopyFrom(src *Board) {
+	if src == nil {
+		panic(errors.New("src is nil"))
+	}
+	*b = *src
+}
+
+// Return the number of contiguous stones of the same color as the stone at
+// pos along the line of dir (in both directions), including the stone at pos.
+// Return 0 if there is no stone at pos.
+func (b *Board) LineLength(pos Position, dir Direction) int {
+	piece := b.Get(pos)
+	if piece != Black && piece != White {
+		return 0
+	}
+	o := orientationOf(dir)
+	if o < 0 {
+		return 0
+	}
+	line := b.lines[colorIndex(piece)][o][lineOf(pos, o)]
+	i := bitOf(pos, o)
+	// Both include the stone at pos itself.
+	upper := bits.TrailingZeros32(^(line >> i))
+	lower := bits.LeadingZeros32(^(line << (31 - i)))
+	return upper + lower - 1
+}
+
+// Return the position k steps from pos along dir, or backward if k is
+// negative, or InvalidPosition if it's outside the board.
+// Unlike Position.Move, it doesn't allocate errors, as it's used in the hot
+// loops of searches.
+func (b *Board) PositionAlong(pos Position, dir Direction, k int) Position {
+	if pos.IsOutOfRange() {
+		return InvalidPosition
+	}
+	dx, dy := dir.Delta()
+	x, y := pos.X()+dx*k, pos.Y()+dy*k
+	if x < 0 || x >= b.size || y < 0 || y >= b.size {
+		return InvalidPosition
+	}
+	return Position(x + y*MaxBoardSize + 1)
+}
+
+// Return the empty positions within dist (in both x and y) from any stone,
+// in ascending order.
+func (b *Board) GetNearbyEmptyPositions(dist int) []Position {
+	mask := uint32(1)<<uint(b.size) - 1
+	var occupied, near [MaxBoardSize]uint32
+	for y := 0; y < b.size; y++ {
+		occupied[y] = b.lines[0][0][y] | b.lines[1][0][y]
+		near[y] = occupied[y]
+		for i := 1; i <= dist; i++ {
+			near[y] |= occupied[y]<<uint(i) | occupied[y]>>uint(i)
+		}
+	}
+	var ps []Position
+	for y := 0; y < b.size; y++ {
+		var row uint32
+		for i := y - dist; i <= y+dist; i++ {
+			if i >= 0 && i < b.size {
+				row |= near[i]
+			}
+		}
+		row &= mask &^ occupied[y]
+		for row != 0 {
+			x := bits.TrailingZeros32(row)
+			ps = append(ps, Position(x+y*MaxBoardSize+1))
+			row &= row - 1
+		}
+	}
+	return ps
+}
+
+func colorIndex(piece Piece) int {
+	if piece == White {
+		return 1
+	}
+	return 0
+}
+
+// Orientations: 0 for horizontal, 1 for vertical, 2 for diagonal
+// (from left-up to right-down) and 3 for anti-diagonal.
+// Return -1 for invalid direction.
+func orientationOf(dir Direction) int {
+	switch dir {
+	case Left, Right:
+		return 0
+	case Up, Down:
+		return 1
+	case LeftUp, RightDown:
+		return 2
+	case RightUp, LeftDown:
+		return 3
+	default:
+		return -1
+	}
+}
+
+func lineOf(pos Position, orientation int) int {
+	x, y := pos.X(), pos.Y()
+	switch orientation {
+	case 0:
+		return y
+	case 1:
+		return x
+	case 2:
+		return x - y + MaxBoardSize - 1
+	default:
+		return x + y
+	}
+}
+
+func bitOf(pos Position, orientation int) uint {
+	if orientation == 1 {
+		return uint(pos.Y())
+	}
+	return uint(pos.X())
+}
@@ -0,0 +1,131 @@
+package board
+
+import "testing"
+
+func TestBoardLineLength(t *testing.T) {
+	b, err := NewBoard(DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeTestStones(t, b, []string{"a1", "b2", "c3", "d4", "f6"}, Black)
+	placeTestStones(t, b, []string{"e5", "e4", "e6", "e7", "o15", "n15"}, White)
+	cases := []struct {
+		pos  string
+		dir  Direction
+		want int
+	}{
+		{"a1", RightDown, 4},
+		{"c3", LeftUp, 4},
+		{"c3", Right, 1},
+		{"e5", Up, 4},
+		{"e5", Down, 4},
+		{"e5", LeftDown, 1},
+		{"f6", RightDown, 1},
+		{"o15", Left, 2},
+		{"o15", Up, 1},
+		{"h8", Right, 0},
+	}
+	for _, c := range cases {
+		pos, err := ParsePosition(c.pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := b.LineLength(pos, c.dir); n != c.want {
+			t.Errorf("LineLength(%v, %d) = %d, want %d", pos, c.dir, n, c.want)
+		}
+	}
+	if b.NumStone() != 11 {
+		t.Errorf("NumStone() = %d, want 11", b.NumStone())
+	}
+
+	c := b.Copy()
+	pos, err := ParsePosition("e5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set(pos, Black)
+	if n := c.LineLength(pos, RightDown); n != 6 {
+		t.Errorf("LineLength(%v) on copy = %d, want 6", pos, n)
+	}
+	if b.Get(pos) != White {
+		t.Error("original board is modified by its copy")
+	}
+	c.Set(pos, 0)
+	if c.Get(pos) != 0 || c.NumStone() != 10 {
+		t.Errorf("after removal, piece: %v, NumStone: %d", c.Get(pos),
+			c.NumStone())
+	}
+	if n := c.LineLength(GetCenterPosition(DefaultBoardSize)-1, Right); n != 0 {
+		t.Errorf("LineLength on empty position = %d", n)
+	}
+}
+
+func TestBoardPositionAlong(t *testing.T) {
+	b, err := NewBoard(DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		pos  string
+		dir  Direction
+		k    int
+		want string // Empty for InvalidPosition.
+	}{
+		{"h8", Right, 2, "j8"},
+		{"h8", Right, -2, "f8"},
+		{"h8", RightUp, 3, "k5"},
+		{"h8", LeftDown, 0, "h8"},
+		{"a1", Left, 1, ""},
+		{"o15", RightDown, 1, ""},
+		{"n8", Right, 2, ""}, // On the largest board, but not on b.
+	}
+	for _, c := range cases {
+		pos, err := ParsePosition(c.pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := ParsePosition(c.want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := b.PositionAlong(pos, c.dir, c.k); p != want {
+			t.Errorf("PositionAlong(%v, %d, %d) = %v, want %v", pos, c.dir,
+				c.k, p, want)
+		}
+	}
+}
+
+func TestGetNearbyEmptyPositions(t *testing.T) {
+	b, err := NewBoard(DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeTestStones(t, b, []string{"a1"}, Black)
+	placeTestStones(t, b, []string{"h8"}, White)
+	ps := b.GetNearbyEmptyPositions(1)
+	if len(ps) != 3+8 {
+		t.Errorf("len: %d, want 11, positions: %v", len(ps), ps)
+	}
+	for i, p := range ps {
+		if b.Get(p) != 0 {
+			t.Errorf("%v is not empty", p)
+		}
+		if i > 0 && ps[i-1] >= p {
+			t.Errorf("positions are not ascending at %d", i)
+		}
+	}
+	if n := len(b.GetNearbyEmptyPositions(2)); n != 8+24 {
+		t.Errorf("len: %d, want 32", n)
+	}
+}
+
+func placeTestStones(tb testing.TB, b *Board, stones []string,
+	piece Piece) {
+	for _, s := range stones {
+		p, err := ParsePosition(s)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		b.Set(p, piece)
+	}
+}
@@ -0,0 +1,16 @@
+package board
+
+const (
+	MinBoardSize     int = 5
+	MaxBoardSize     int = 26 // Columns are labeled by letters "A" to "Z".
+	DefaultBoardSize int = 15
+)
+
+const NumPosition int = MaxBoardSize * MaxBoardSize
+
+// Position ∈ [1, 676]. 0 for invalid.
+const (
+	InvalidPosition Position = 0
+	MinPosition     Position = 1
+	MaxPosition              = Position(NumPosition)
+)
@@ -0,0 +1,43 @@
+package board
+
+type Direction int8
+
+const (
+	Left Direction = iota + 1
+	LeftUp
+	Up
+	RightUp
+	Right
+	RightDown
+	Down
+	LeftDown
+)
+
+// The four lines through a position, one direction for each.
+// Each line is scanned in both directions.
+var LineDirections = [...]Direction{Right, Down, RightDown, RightUp}
+
+// Return the change of x and y when moving one step along the direction.
+// Return 0, 0 for invalid direction.
+func (d Direction) Delta() (dx, dy int) {
+	switch d {
+	case Left:
+		return -1, 0
+	case LeftUp:
+		return -1, -1
+	case Up:
+		return 0, -1
+	case RightUp:
+		return 1, -1
+	case Right:
+		return 1, 0
+	case RightDown:
+		return 1, 1
+	case Down:
+		return 0, 1
+	case LeftDown:
+		return -1, 1
+	default:
+		return 0, 0
+	}
+}
@@ -0,0 +1,49 @@
+package board
+
+import "fmt"
+
+type UnknownPositionError struct {
+	s string
+}
+
+type PositionOutOfRangeError struct {
+	x, y, boardSize int
+}
+
+type BoardSizeOutOfRangeError struct {
+	size int
+}
+
+func NewUnknownPositionError(s string) error {
+	return &UnknownPositionError{s: s}
+}
+
+func (upe *UnknownPositionError) Error() string {
+	return fmt.Sprintf("position %q is unknown", upe.s)
+}
+
+func NewPositionOutOfRangeError(x, y, boardSize int) error {
+	if x >= 0 && x < boardSize && y >= 0 && y < boardSize {
+		panic(fmt.Errorf("position(x: %d, y: %d) is NOT out of range(0-%d), "+
+			"but treat it as an error", x, y, boardSize-1))
+	}
+	return &PositionOutOfRangeError{x: x, y: y, boardSize: boardSize}
+}
+
+func (pore *PositionOutOfRangeError) Error() string {
+	return fmt.Sprintf("position is out of range(0-%d), x: %d, y: %d",
+		pore.boardSize-1, pore.x, pore.y)
+}
+
+func NewBoardSizeOutOfRangeError(size int) error {
+	if size >= MinBoardSize && size <= MaxBoardSize {
+		panic(fmt.Errorf("board size %d is NOT out of range(%d-%d), "+
+			"but treat it as an error", size, MinBoardSize, MaxBoardSize))
+	}
+	return &BoardSizeOutOfRangeError{size: size}
+}
+
+func (bsore *BoardSizeOutOfRangeError) Error() string {
+	return fmt.Sprintf("board size %d is out of range(%d-%d)",
+		bsore.size, MinBoardSize, MaxBoardSize)
+}
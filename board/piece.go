@@ -1,4 +1,4 @@
-package main
+package board
 
 import "strings"
 
@@ -55,3 +55,12 @@ func (p *Piece) UnmarshalText(text []byte) error {
 	*p = ParsePiece(string(text))
 	return nil
 }
+
+// Return the color of the stone placed at the specified step,
+// which starts from 1.
+func PieceOfStep(step uint) Piece {
+	if step%2 == 1 {
+		return Black
+	}
+	return White
+}
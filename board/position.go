@@ -0,0 +1,119 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Position ∈ [1, 676]. 0 for invalid.
+// Positions are laid out on the largest board (MaxBoardSize × MaxBoardSize),
+// so that they are independent of the board size.
+// Use IsOnBoard to check whether a position is on a smaller board.
+type Position uint16
+
+func GetPosition(x, y int) (Position, error) {
+	if x < 0 || x >= MaxBoardSize || y < 0 || y >= MaxBoardSize {
+		return InvalidPosition, NewPositionOutOfRangeError(x, y, MaxBoardSize)
+	}
+	return Position(x + y*MaxBoardSize + 1), nil
+}
+
+// Return the center of the board, e.g. "H8" for 15×15 board.
+// boardSize should be in [MinBoardSize, MaxBoardSize].
+func GetCenterPosition(boardSize int) Position {
+	offset := boardSize / 2
+	return Position(offset + offset*MaxBoardSize + 1)
+}
+
+// Return all positions on the board, in ascending order.
+func GetAllPositions(boardSize int) []Position {
+	if boardSize < 0 {
+		boardSize = 0
+	} else if boardSize > MaxBoardSize {
+		boardSize = MaxBoardSize
+	}
+	ps := make([]Position, 0, boardSize*boardSize)
+	for y := 0; y < boardSize; y++ {
+		p := Position(y*MaxBoardSize + 1)
+		for x := 0; x < boardSize; x++ {
+			ps = append(ps, p)
+			p++
+		}
+	}
+	return ps
+}
+
+func ParsePosition(s string) (Position, error) {
+	if s == "" || strings.EqualFold(s, "<nil>") ||
+		strings.EqualFold(s, "<invalid position>") {
+		return InvalidPosition, nil
+	}
+	var x, y int
+	r, w := utf8.DecodeRuneInString(s)
+	r = unicode.ToUpper(r)
+	if r >= 'A' && r <= 'Z' {
+		x = int(r - 'A')
+		yU64, err := strconv.ParseUint(s[w:], 10, 8)
+		if err != nil {
+			return InvalidPosition, NewUnknownPositionError(s)
+		}
+		y = int(yU64) - 1
+	} else {
+		return InvalidPosition, NewUnknownPositionError(s)
+	}
+	return GetPosition(x, y)
+}
+
+func (p Position) X() int {
+	if p == InvalidPosition {
+		return -1
+	}
+	return int(p-1) % MaxBoardSize
+}
+
+func (p Position) Y() int {
+	if p == InvalidPosition {
+		return -1
+	}
+	return int(p-1) / MaxBoardSize
+}
+
+// Return the offset of x from the center of the board.
+func (p Position) XOffset(boardSize int) int {
+	return p.X() - boardSize/2
+}
+
+// Return the offset of y from the center of the board.
+func (p Position) YOffset(boardSize int) int {
+	return p.Y() - boardSize/2
+}
+
+func (p Position) String() string {
+	if p == InvalidPosition {
+		return "<invalid position>"
+	}
+	x, y := p.X(), p.Y()
+	if x < 0 || x >= MaxBoardSize || y < 0 || y >= MaxBoardSize {
+		return fmt.Sprintf("<out of range position>(%d, %d)", x, y)
+	}
+	return fmt.Sprintf("%c%d", 'A'+x, y+1)
+}
+
+func (p Position) IsOutOfRange() bool {
+	return p < MinPosition || p > MaxPosition
+}
+
+func (p Position) IsOnBoard(boardSize int) bool {
+	return !p.IsOutOfRange() && p.X() < boardSize && p.Y() < boardSize
+}
+
+func (p Position) Move(x, y int) (Position, error) {
+	pX, pY := p.X(), p.Y()
+	if p.IsOutOfRange() {
+		return InvalidPosition, NewPositionOutOfRangeError(pX, pY, MaxBoardSize)
+	}
+	return GetPosition(pX+x, pY+y)
+}
@@ -0,0 +1,59 @@
+package board
+
+import "testing"
+
+func TestPositionParseAndFormatString(t *testing.T) {
+	for p := MinPosition; p <= MaxPosition; p++ {
+		s := p.String()
+		tmp, err := ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != tmp {
+			t.Errorf("%v != %v", p, tmp)
+		}
+	}
+	p := InvalidPosition
+	s := p.String()
+	tmp, err := ParsePosition(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != tmp {
+		t.Errorf("%v != %v", p, tmp)
+	}
+}
+
+func TestGetAllPositions(t *testing.T) {
+	for _, size := range []int{MinBoardSize, DefaultBoardSize, 20, MaxBoardSize} {
+		ps := GetAllPositions(size)
+		if len(ps) != size*size {
+			t.Errorf("size: %d, len: %d", size, len(ps))
+		}
+		for i, p := range ps {
+			if !p.IsOnBoard(size) {
+				t.Errorf("size: %d, %v is not on board", size, p)
+			}
+			if i > 0 && ps[i-1] >= p {
+				t.Errorf("size: %d, positions are not ascending at %d", size, i)
+			}
+		}
+		last, err := GetPosition(size-1, size-1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ps[len(ps)-1] != last {
+			t.Errorf("size: %d, last: %v, want %v", size, ps[len(ps)-1], last)
+		}
+		if size < MaxBoardSize {
+			p, err := GetPosition(size, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.IsOnBoard(size) {
+				t.Errorf("size: %d, %v is on board", size, p)
+			}
+		}
+		t.Logf("size: %d, center: %v", size, GetCenterPosition(size))
+	}
+}
@@ -0,0 +1,204 @@
+// Package book provides opening books of gomoku games.
+//
+// Positions in a book are keyed by their canonical forms under the eight
+// symmetries of the board, so a book move is found in all rotations and
+// reflections of the position it's added for.
+package book
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+type Move struct {
+	Pos board.Position
+	// Sum of the weights added for the move.
+	Weight int
+}
+
+type Book struct {
+	boardSize int
+	// Moves in the canonical orientation, indexed by the canonical keys of
+	// the positions before them, in the order they're added.
+	entries map[string][]Move
+}
+
+func New(boardSize int) (*Book, error) {
+	if boardSize < board.MinBoardSize || boardSize > board.MaxBoardSize {
+		return nil, board.NewBoardSizeOutOfRangeError(boardSize)
+	}
+	return &Book{
+		boardSize: boardSize,
+		entries:   make(map[string][]Move),
+	}, nil
+}
+
+func (bk *Book) BoardSize() int {
+	return bk.boardSize
+}
+
+// Return the number of positions in the book.
+func (bk *Book) Len() int {
+	if bk == nil {
+		return 0
+	}
+	return len(bk.entries)
+}
+
+// Add the move pos for the position on b, or add weight to it if it's
+// already in the book, including in any symmetry of the position.
+// The stone at pos is for the player to move, by the number of stones on b.
+// weight should be positive.
+func (bk *Book) Add(b *board.Board, pos board.Position, weight int) error {
+	if b == nil {
+		return errors.New("board is nil")
+	}
+	if b.Size() != bk.boardSize {
+		return fmt.Errorf("board size %d is not the size of the book(%d)",
+			b.Size(), bk.boardSize)
+	}
+	if !pos.IsOnBoard(bk.boardSize) {
+		return board.NewPositionOutOfRangeError(pos.X(), pos.Y(), bk.boardSize)
+	}
+	if b.Get(pos) != 0 {
+		return fmt.Errorf("position %v is not empty", pos)
+	}
+	if weight <= 0 {
+		return fmt.Errorf("weight(%d) is not positive", weight)
+	}
+	stones := stonesOf(b)
+	var numBlack int
+	for _, st := range stones {
+		if st.piece == board.Black {
+			numBlack++
+		}
+	}
+	if n := numBlack*2 - len(stones); n != 0 && n != 1 {
+		return errors.New("numbers of black and white stones are not by turns")
+	}
+	key, syms := canonicalize(stones, bk.boardSize)
+	// Equivalent moves on a symmetric position are added as the same one.
+	cpos := syms[0].Apply(pos, bk.boardSize)
+	for _, s := range syms[1:] {
+		if p := s.Apply(pos, bk.boardSize); p < cpos {
+			cpos = p
+		}
+	}
+	moves := bk.entries[key]
+	for i := range moves {
+		if moves[i].Pos == cpos {
+			moves[i].Weight += weight
+			return nil
+		}
+	}
+	bk.entries[key] = append(moves, Move{Pos: cpos, Weight: weight})
+	return nil
+}
+
+// Add the last move of line for the position after the moves before it,
+// which are placed by turns from the empty board.
+func (bk *Book) AddLine(line []board.Position, weight int) error {
+	if len(line) == 0 {
+		return errors.New("line is empty")
+	}
+	b, err := board.NewBoard(bk.boardSize)
+	if err != nil {
+		return err
+	}
+	last := len(line) - 1
+	for i, pos := range line[:last] {
+		if !pos.IsOnBoard(bk.boardSize) {
+			return board.NewPositionOutOfRangeError(pos.X(), pos.Y(),
+				bk.boardSize)
+		}
+		if b.Get(pos) != 0 {
+			return fmt.Errorf("move %d(%v) is on a stone", i+1, pos)
+		}
+		b.Set(pos, board.PieceOfStep(uint(i+1)))
+	}
+	return bk.Add(b, line[last], weight)
+}
+
+// Return the book moves for the position on b, in the orientation of b,
+// in descending order of weights. Return nil if the position is not in
+// the book, or bk is nil.
+func (bk *Book) Lookup(b *board.Board) []Move {
+	if bk == nil || b == nil || b.Size() != bk.boardSize {
+		return nil
+	}
+	key, syms := canonicalize(stonesOf(b), bk.boardSize)
+	cmoves := bk.entries[key]
+	if len(cmoves) == 0 {
+		return nil
+	}
+	moves := make([]Move, len(cmoves))
+	for i, m := range cmoves {
+		moves[i] = Move{Pos: syms[0].Invert(m.Pos, bk.boardSize),
+			Weight: m.Weight}
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Weight > moves[j].Weight
+	})
+	return moves
+}
+
+type stone struct {
+	pos   board.Position
+	piece board.Piece
+}
+
+// Return the stones on b, in ascending order of positions.
+func stonesOf(b *board.Board) []stone {
+	stones := make([]stone, 0, b.NumStone())
+	for _, pos := range board.GetAllPositions(b.Size()) {
+		if piece := b.Get(pos); piece != 0 {
+			stones = append(stones, stone{pos: pos, piece: piece})
+		}
+	}
+	return stones
+}
+
+// Return the least key of stones under all symmetries, and the symmetries
+// mapping stones to it.
+func canonicalize(stones []stone, boardSize int) (key string, syms []Symmetry) {
+	mapped := make([]stone, len(stones))
+	for s := Symmetry(0); int(s) < NumSymmetry; s++ {
+		for i, st := range stones {
+			mapped[i] = stone{pos: s.Apply(st.pos, boardSize), piece: st.piece}
+		}
+		sort.Slice(mapped, func(i, j int) bool {
+			return mapped[i].pos < mapped[j].pos
+		})
+		k := encodeStones(mapped)
+		if len(syms) == 0 || k < key {
+			key, syms = k, append(syms[:0], s)
+		} else if k == key {
+			syms = append(syms, s)
+		}
+	}
+	return
+}
+
+// Encode each stone in three bytes: the position in big-endian, and
+// the piece. Keys of stones in the same order compare as the positions.
+func encodeStones(stones []stone) string {
+	buf := make([]byte, 0, len(stones)*3)
+	for _, st := range stones {
+		buf = append(buf, byte(st.pos>>8), byte(st.pos), byte(st.piece))
+	}
+	return string(buf)
+}
+
+func decodeStones(key string) []stone {
+	stones := make([]stone, 0, len(key)/3)
+	for i := 0; i+2 < len(key); i += 3 {
+		stones = append(stones, stone{
+			pos:   board.Position(key[i])<<8 | board.Position(key[i+1]),
+			piece: board.Piece(key[i+2]),
+		})
+	}
+	return stones
+}
@@ -0,0 +1,179 @@
+package book
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+func TestSymmetry(t *testing.T) {
+	const boardSize = 15
+	for s := Symmetry(0); int(s) < NumSymmetry; s++ {
+		for _, pos := range board.GetAllPositions(boardSize) {
+			p := s.Apply(pos, boardSize)
+			if !p.IsOnBoard(boardSize) {
+				t.Fatalf("symmetry %d: %v -> %v", s, pos, p)
+			}
+			if q := s.Invert(p, boardSize); q != pos {
+				t.Fatalf("symmetry %d: %v -> %v -> %v", s, pos, p, q)
+			}
+		}
+	}
+	pos, err := board.ParsePosition("J8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := make(map[string]bool)
+	for s := Symmetry(0); int(s) < NumSymmetry; s++ {
+		images[s.Apply(pos, boardSize).String()] = true
+	}
+	for _, want := range []string{"J8", "F8", "H10", "H6"} {
+		if !images[want] {
+			t.Errorf("images of J8: %v, want %s", images, want)
+		}
+	}
+	if len(images) != 4 {
+		t.Errorf("images of J8: %v", images)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	bk, err := Read(strings.NewReader("H8 I9 J8 # a comment\n"), 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		stones []string
+		want   string
+	}{
+		{[]string{"H8", "I9"}, "J8"},
+		{[]string{"H8", "G7"}, "F8"},
+		{[]string{"H8", "G9"}, "F8"},
+		{[]string{"H8", "I7"}, "J8"},
+	}
+	for _, c := range cases {
+		b := mustBoard(t, c.stones...)
+		moves := bk.Lookup(b)
+		if len(moves) != 1 {
+			t.Errorf("%v: moves: %v", c.stones, moves)
+			continue
+		}
+		// The move may be another one equivalent to the wanted one,
+		// as the positions are symmetric by a diagonal.
+		b.Set(moves[0].Pos, board.Black)
+		if mustKey(b) != mustKey(mustBoard(t, append(c.stones, c.want)...)) {
+			t.Errorf("%v: move: %v, want %s", c.stones, moves[0].Pos, c.want)
+		}
+	}
+	if moves := bk.Lookup(mustBoard(t, "H8", "I8")); moves != nil {
+		t.Errorf("moves of a position not in the book: %v", moves)
+	}
+	var nilBook *Book
+	if nilBook.Lookup(mustBoard(t)) != nil || nilBook.Len() != 0 {
+		t.Error("nil book is not empty")
+	}
+}
+
+func TestAddEquivalentMoves(t *testing.T) {
+	bk, err := Read(strings.NewReader("H8 I9\nH8 G7 2\nH8 I8\n"), 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves := bk.Lookup(mustBoard(t, "H8"))
+	if len(moves) != 2 || moves[0].Weight != 3 || moves[1].Weight != 1 {
+		t.Fatalf("moves: %v", moves)
+	}
+	x, y := moves[0].Pos.XOffset(15), moves[0].Pos.YOffset(15)
+	if x*x != 1 || y*y != 1 {
+		t.Errorf("diagonal move: %v", moves[0].Pos)
+	}
+	x, y = moves[1].Pos.XOffset(15), moves[1].Pos.YOffset(15)
+	if x*x+y*y != 1 {
+		t.Errorf("direct move: %v", moves[1].Pos)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	text := "H8\nH8 I9 2\nH8 I8 3\n\n# Comment.\nH8 I9 J10 A1 B2\n"
+	bk, err := Read(strings.NewReader(text), 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = bk.Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("book:\n%s", buf.String())
+	bk2, err := Read(&buf, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bk2.Len() != bk.Len() || bk.Len() != 3 {
+		t.Fatalf("number of positions: %d, then %d", bk.Len(), bk2.Len())
+	}
+	for key, moves := range bk.entries {
+		moves2 := bk2.entries[key]
+		if len(moves2) != len(moves) {
+			t.Errorf("moves: %v, then %v", moves, moves2)
+			continue
+		}
+		for i := range moves {
+			if moves2[i] != moves[i] {
+				t.Errorf("moves: %v, then %v", moves, moves2)
+				break
+			}
+		}
+	}
+
+	for _, text := range []string{"H8 H8", "H8 X1", "H8 I9 0", "Q", "I9 -1"} {
+		_, err = Read(strings.NewReader(text), 15)
+		if err == nil {
+			t.Errorf("no error for %q", text)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	bk, err := Default(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bk.Len() != 4 {
+		t.Errorf("number of positions: %d", bk.Len())
+	}
+	moves := bk.Lookup(mustBoard(t))
+	if len(moves) != 1 || moves[0].Pos.String() != "H8" {
+		t.Errorf("first moves: %v", moves)
+	}
+	bk, err = Default(19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bk.Len() != 0 {
+		t.Errorf("number of positions on 19×19 board: %d", bk.Len())
+	}
+}
+
+// Return the 15×15 board with stones placed by turns.
+func mustBoard(t *testing.T, stones ...string) *board.Board {
+	b, err := board.NewBoard(15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range stones {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Set(pos, board.PieceOfStep(uint(i+1)))
+	}
+	return b
+}
+
+func mustKey(b *board.Board) string {
+	key, _ := canonicalize(stonesOf(b), b.Size())
+	return key
+}
@@ -0,0 +1,28 @@
+package book
+
+import (
+	"strings"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// The built-in book for 15×15 boards, in the text format of Read.
+// It covers the first three moves, around the center.
+const defaultText string = `# The first move at the center.
+H8
+# The second move, diagonal(indirect) or direct.
+H8 I9 2
+H8 I8
+# The third move, adjacent to both stones.
+H8 I9 I8
+H8 I8 I9
+`
+
+// Return the built-in book for the board of boardSize.
+// It's empty for boards other than board.DefaultBoardSize (15×15).
+func Default(boardSize int) (*Book, error) {
+	if boardSize != board.DefaultBoardSize {
+		return New(boardSize)
+	}
+	return Read(strings.NewReader(defaultText), boardSize)
+}
@@ -0,0 +1,13 @@
+package book
+
+type Settings struct {
+	// Text file of the book, see Read. Empty for the built-in book.
+	File string `json:"file,omitempty"`
+	// Maximum number of stones on the board to consult the book.
+	// Not positive to disable the book.
+	MaxStones int `json:"max_stones,omitempty"`
+}
+
+func NewSettings() *Settings {
+	return &Settings{MaxStones: 8}
+}
@@ -0,0 +1,49 @@
+package book
+
+import "github.com/donyori/ucashw_gt_gomoku/board"
+
+// Symmetry of the square board, one of the four rotations and
+// four reflections. The zero value is the identity.
+// Bit 2 transposes x and y, then bit 0 flips x, and bit 1 flips y.
+type Symmetry int8
+
+const NumSymmetry int = 8
+
+// Return the position pos is mapped to by s on the board of boardSize.
+// It returns InvalidPosition if pos is not on the board.
+func (s Symmetry) Apply(pos board.Position, boardSize int) board.Position {
+	if !pos.IsOnBoard(boardSize) {
+		return board.InvalidPosition
+	}
+	x, y := pos.X(), pos.Y()
+	if s&4 != 0 {
+		x, y = y, x
+	}
+	if s&1 != 0 {
+		x = boardSize - 1 - x
+	}
+	if s&2 != 0 {
+		y = boardSize - 1 - y
+	}
+	p, _ := board.GetPosition(x, y)
+	return p
+}
+
+// Return the position mapped to pos by s, i.e. undo Apply.
+func (s Symmetry) Invert(pos board.Position, boardSize int) board.Position {
+	if !pos.IsOnBoard(boardSize) {
+		return board.InvalidPosition
+	}
+	x, y := pos.X(), pos.Y()
+	if s&2 != 0 {
+		y = boardSize - 1 - y
+	}
+	if s&1 != 0 {
+		x = boardSize - 1 - x
+	}
+	if s&4 != 0 {
+		x, y = y, x
+	}
+	p, _ := board.GetPosition(x, y)
+	return p
+}
@@ -0,0 +1,109 @@
+package book
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// Read a book in text format from r, for the board of boardSize.
+//
+// Each line of the text is a line of play from the empty board, in
+// positions separated by spaces, e.g. "H8 I9 I8", optionally followed by
+// a positive integer weight, 1 by default. Only the last move is added to
+// the book, for the position after the moves before it, see Book.AddLine.
+// Text after "#" is a comment, and blank lines are skipped.
+func Read(r io.Reader, boardSize int) (*Book, error) {
+	if r == nil {
+		return nil, errors.New("r is nil")
+	}
+	bk, err := New(boardSize)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		weight := 1
+		if w, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+			weight = w
+			fields = fields[:len(fields)-1]
+		}
+		line := make([]board.Position, len(fields))
+		for i, field := range fields {
+			line[i], err = board.ParsePosition(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+		}
+		err = bk.AddLine(line, weight)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	return bk, nil
+}
+
+// Write bk to w in the text format of Read, one line per book move with
+// its weight. Positions are written in their canonical orientation.
+func (bk *Book) Write(w io.Writer) error {
+	if w == nil {
+		return errors.New("w is nil")
+	}
+	keys := make([]string, 0, len(bk.entries))
+	for key := range bk.entries {
+		keys = append(keys, key)
+	}
+	// Shorter lines first.
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	bw := bufio.NewWriter(w)
+	for _, key := range keys {
+		var blacks, whites []string
+		for _, st := range decodeStones(key) {
+			if st.piece == board.Black {
+				blacks = append(blacks, st.pos.String())
+			} else {
+				whites = append(whites, st.pos.String())
+			}
+		}
+		var prefix strings.Builder
+		for i := range blacks {
+			prefix.WriteString(blacks[i])
+			prefix.WriteByte(' ')
+			if i < len(whites) {
+				prefix.WriteString(whites[i])
+				prefix.WriteByte(' ')
+			}
+		}
+		for _, m := range bk.entries[key] {
+			_, err := fmt.Fprintf(bw, "%s%v %d\n", prefix.String(), m.Pos,
+				m.Weight)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/donyori/ucashw_gt_gomoku/book"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+)
+
+// Build an opening book from the game record files, and write it to
+// filename in text format. The board size and the maximum number of
+// stones of the book are taken from settings.
+func buildBook(filename string, recordFiles []string, settings *Settings) error {
+	if len(recordFiles) == 0 {
+		return errors.New("no game record file is given")
+	}
+	maxStones := book.NewSettings().MaxStones
+	if settings.Ai.Book != nil && settings.Ai.Book.MaxStones > 0 {
+		maxStones = settings.Ai.Book.MaxStones
+	}
+	bk, err := book.New(settings.BoardSize)
+	if err != nil {
+		return err
+	}
+	for _, name := range recordFiles {
+		err = addRecordToBook(bk, name, maxStones)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = bk.Write(f)
+	if err != nil {
+		f.Close() // Ignore error.
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d positions from %d game records are written to %s.\n",
+		bk.Len(), len(recordFiles), filename)
+	return nil
+}
+
+func addRecordToBook(bk *book.Book, filename string, maxStones int) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // Ignore error.
+	gr := new(game.GameRecord)
+	err = json.NewDecoder(f).Decode(gr)
+	if err != nil {
+		return err
+	}
+	return gr.AddToBook(bk, maxStones)
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/format"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+var stdinScanner *bufio.Scanner = bufio.NewScanner(os.Stdin)
+
+// Number of the candidate moves printed by the analyze command.
+const numAnalysisMoves int = 10
+
+// Number of the alternatives suggested by the hint command besides
+// the best move.
+const numHintAlternatives int = 3
+
+// Settings to print boards, and the time to search for the hint command,
+// set by main after loading the settings.
+var (
+	bpSettings    *format.BoardPrintSettings
+	hintTimeLimit time.Duration
+)
+
+const inputPositionHelp string = `type "h" or "help" for commands, ` +
+	`"q" or "quit" to exit`
+
+// Command input by user instead of a position, which is handled by the caller.
+type Command struct {
+	Name string // In lower case.
+	Arg  string
+}
+
+// Commands returned by AskForInputPosition.
+var commandNames = map[string]bool{
+	"save": true,
+	"load": true,
+}
+
+// Usages of commands available when asking for a position.
+var commandUsages = [...][2]string{
+	{`"u" or "undo"`, "Take back your last move."},
+	{`"hint"`, "Suggest a move for you."},
+	{`"solve"`, "Search a forced win for you by continuous fours or threats."},
+	{`"analyze [TIME]"`, "Show the AI's analysis, searching for TIME(e.g. 10s)."},
+	{`"save [FILE]"`, "Save the game record to FILE(SGF if it ends with .sgf)."},
+	{`"load [FILE]"`, "Load a game record from FILE(SGF if it ends with .sgf)."},
+	{`"q" or "quit"`, "Exit."},
+}
+
+func ReadLine() (string, error) {
+	if stdinScanner.Scan() {
+		return stdinScanner.Text(), nil
+	}
+	return "", stdinScanner.Err()
+}
+
+// Return InvalidPosition and nil command if user want to quit the game.
+// If user inputs a command in commandNames, return it with InvalidPosition.
+func AskForInputPosition(g *game.Game) (board.Position, *Command, error) {
+	fmt.Print(turnString(g), " - Your turn(", inputPositionHelp, "): ")
+	// Keep searching while waiting for the user. It's stopped by the next
+	// change of the game, e.g. PlaceByUser.
+	err := g.StartPondering()
+	if err != nil {
+		return board.InvalidPosition, nil, err
+	}
+	pos := board.InvalidPosition
+	for pos == board.InvalidPosition {
+		input, err := ReadLine()
+		if err != nil {
+			return board.InvalidPosition, nil, err
+		}
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		inputUpper := strings.ToUpper(input)
+		if inputUpper == "Q" || inputUpper == "QUIT" {
+			return board.InvalidPosition, nil, nil
+		}
+		if inputUpper == "H" || inputUpper == "HELP" {
+			for _, usage := range commandUsages {
+				fmt.Printf("  %-16s %s\n", usage[0], usage[1])
+			}
+			fmt.Print("Please input again(", inputPositionHelp, "): ")
+			continue
+		}
+		if inputUpper == "U" || inputUpper == "UNDO" {
+			err = undoForUser(g)
+			if err != nil {
+				return board.InvalidPosition, nil, err
+			}
+			continue
+		}
+		if inputUpper == "SOLVE" {
+			err = solveForUser(g)
+			if err != nil {
+				return board.InvalidPosition, nil, err
+			}
+			continue
+		}
+		if inputUpper == "HINT" {
+			err = hintForUser(g)
+			if err != nil {
+				return board.InvalidPosition, nil, err
+			}
+			continue
+		}
+		fields := strings.SplitN(input, " ", 2)
+		name := strings.ToLower(fields[0])
+		var arg string
+		if len(fields) > 1 {
+			arg = strings.TrimSpace(fields[1])
+		}
+		if name == "analyze" {
+			err = analyzeForUser(g, arg)
+			if err != nil {
+				return board.InvalidPosition, nil, err
+			}
+			continue
+		}
+		if commandNames[name] {
+			return board.InvalidPosition, &Command{Name: name, Arg: arg}, nil
+		}
+		pos, err = board.ParsePosition(input)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Print("Please input again(", inputPositionHelp, "): ")
+			pos = board.InvalidPosition
+			continue
+		}
+		// Check a copy, as IsLegal sets stones temporarily, and the board
+		// is read by pondering.
+		isLegal, hint, err := rules.IsLegal(g.Settings.Rule, g.Board.Copy(),
+			g.Step()+1, pos)
+		if err != nil {
+			return board.InvalidPosition, nil, err
+		}
+		if !isLegal {
+			fmt.Println("Position", pos, "is illegal.")
+			if hint != "" {
+				fmt.Println(hint)
+			}
+			fmt.Print("Please input again(", inputPositionHelp, "): ")
+			pos = board.InvalidPosition
+		}
+	}
+	return pos, nil, nil
+}
+
+// Return 0 if user want to quit the game.
+func AskForColorChoice(g *game.Game) (game.ColorChoice, error) {
+	canPlaceTwo := g.Phase == game.ColorChoicePhase &&
+		g.Settings.Rule.Opening() == rules.Swap2Opening
+	options := `"b" for Black, "w" for White`
+	if canPlaceTwo {
+		options += `, "p" to place two more stones`
+	}
+	fmt.Print(turnString(g), " - Your choice(", options,
+		`, "q" or "quit" to exit): `)
+	for {
+		input, err := ReadLine()
+		if err != nil {
+			return 0, err
+		}
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		inputUpper := strings.ToUpper(input)
+		if inputUpper == "Q" || inputUpper == "QUIT" {
+			return 0, nil
+		}
+		choice := game.ParseColorChoice(input)
+		if choice == game.ChooseBlack || choice == game.ChooseWhite ||
+			(choice == game.PlaceTwoMore && canPlaceTwo) {
+			return choice, nil
+		}
+		fmt.Printf("Choice %q is unknown.\n", input)
+		fmt.Print("Please input again(", options, `, "q" or "quit" to exit): `)
+	}
+}
+
+// Take back the user's last move and the moves after it,
+// then print the board and ask for input again.
+func undoForUser(g *game.Game) error {
+	n := g.NumUndoForUser()
+	if n == 0 {
+		fmt.Println("Nothing to undo.")
+		fmt.Print("Please input again(", inputPositionHelp, "): ")
+		return nil
+	}
+	err := g.Undo(n)
+	if err != nil {
+		return err
+	}
+	boardStr, err := format.PrintBoardToString(g.Board, bpSettings)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Took back", n, "move(s).")
+	fmt.Println()
+	fmt.Println(boardStr)
+	fmt.Println()
+	fmt.Print(turnString(g), " - Your turn(", inputPositionHelp, "): ")
+	return g.StartPondering()
+}
+
+// Search the position for the time in arg, or the time in settings if arg is
+// empty, then print the analysis and ask for input again.
+func analyzeForUser(g *game.Game, arg string) error {
+	var budget time.Duration
+	if arg != "" {
+		var err error
+		budget, err = time.ParseDuration(arg)
+		if err != nil || budget <= 0 {
+			fmt.Printf("Time %q is invalid.\n", arg)
+			fmt.Print("Please input again(", inputPositionHelp, "): ")
+			return nil
+		}
+	}
+	fmt.Println("Analyzing...")
+	a, err := g.Analyze(budget)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	fmt.Print(format.PrintAnalysisToString(a, numAnalysisMoves))
+	fmt.Println()
+	fmt.Print(turnString(g), " - Your turn(", inputPositionHelp, "): ")
+	return g.StartPondering()
+}
+
+// Search the position for hintTimeLimit, then suggest the best move and
+// the top alternatives with their win rates, and ask for input again.
+func hintForUser(g *game.Game) error {
+	fmt.Println("Thinking...")
+	a, err := g.Analyze(hintTimeLimit)
+	if err != nil {
+		return err
+	}
+	if len(a.Moves) == 0 {
+		fmt.Println("No move to suggest.")
+	} else {
+		fmt.Println("Suggested move:", moveHintString(a.Moves[0]))
+		alts := a.Moves[1:]
+		if len(alts) > numHintAlternatives {
+			alts = alts[:numHintAlternatives]
+		}
+		if len(alts) > 0 {
+			altStrs := make([]string, len(alts))
+			for i := range alts {
+				altStrs[i] = moveHintString(alts[i])
+			}
+			fmt.Println("Alternatives:", strings.Join(altStrs, ", "))
+		}
+	}
+	fmt.Print(turnString(g), " - Your turn(", inputPositionHelp, "): ")
+	return g.StartPondering()
+}
+
+// Search a forced win of the user by VCF and VCT, print the winning line,
+// and ask for input again.
+func solveForUser(g *game.Game) error {
+	fmt.Println("Solving...")
+	line, err := g.Solve(context.Background())
+	if err != nil {
+		return err
+	}
+	if line == nil {
+		fmt.Println("No forced win is found.")
+	} else {
+		strs := make([]string, len(line))
+		for i := range line {
+			strs[i] = line[i].String()
+		}
+		fmt.Println("Forced win:", strings.Join(strs, " "))
+	}
+	fmt.Print(turnString(g), " - Your turn(", inputPositionHelp, "): ")
+	return g.StartPondering()
+}
+
+func moveHintString(m game.MoveAnalysis) string {
+	if m.Proof != mcts.NotProven {
+		return fmt.Sprintf("%v(%v)", m.Pos, m.Proof)
+	}
+	return fmt.Sprintf("%v(win rate %.1f%%)", m.Pos, m.WinRate*100.)
+}
+
+func turnString(g *game.Game) string {
+	s := fmt.Sprint("Turn ", g.Step()/2+1)
+	switch g.Phase {
+	case game.OpeningPhase:
+		return fmt.Sprintf("%s - Opening stone %d of %d(%v)", s,
+			g.Step()+1, rules.NumSwapOpeningStones, g.NextTurn())
+	case game.ExtraOpeningPhase:
+		return fmt.Sprintf("%s - Extra opening stone %d of %d(%v)", s,
+			g.Step()+1-rules.NumSwapOpeningStones, rules.NumSwap2ExtraStones,
+			g.NextTurn())
+	case game.ColorChoicePhase, game.FinalColorChoicePhase:
+		return s + " - Color choice"
+	default:
+		return s
+	}
+}
+
+func PrintWelcome(w io.Writer) {
+	if w == nil {
+		w = os.Stdout
+	}
+	bar := "------------------------------------------------------------"
+	fmt.Fprintln(w, bar)
+	fmt.Fprintln(w, "Welcome to Gomoku game!")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  You need input coordinates to place your stone.")
+	fmt.Fprintln(w, "    Coordinates format: Letter(for column)+Number(for row)")
+	fmt.Fprintln(w, `    e.g. "H8" is the center of the 15×15 board`)
+	fmt.Fprintln(w, `  Type "h" or "help" for other commands, e.g. undo, save and load.`)
+	fmt.Fprintln(w, "  You can change game settings in file:")
+	fmt.Fprintln(w, "   ", SettingsPath)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "                           Developed by Yuan GAO.")
+	fmt.Fprintln(w, bar)
+}
+
+// Returned by the user's player if the user wants to quit the game.
+var errQuit = errors.New("user quits the game")
+
+// consolePlayer is the user on the console. Commands input by the user are
+// run before returning a position. If a game record is loaded,
+// runner.Game is replaced.
+type consolePlayer struct {
+	runner *game.Runner
+}
+
+func (p *consolePlayer) NextMove(ctx context.Context, g *game.Game) (
+	board.Position, error) {
+	for {
+		pos, cmd, err := AskForInputPosition(g)
+		if err != nil {
+			return board.InvalidPosition, err
+		}
+		if cmd == nil {
+			if pos == board.InvalidPosition {
+				return board.InvalidPosition, errQuit
+			}
+			return pos, nil
+		}
+		loaded, err := runCommand(g, cmd)
+		if err != nil {
+			return board.InvalidPosition, err
+		}
+		if loaded != g {
+			p.runner.Game = loaded
+			return board.InvalidPosition, nil
+		}
+	}
+}
+
+func (p *consolePlayer) Notify(move board.Position) {}
+
+func (p *consolePlayer) ChooseColor(ctx context.Context, g *game.Game) (
+	game.ColorChoice, error) {
+	choice, err := AskForColorChoice(g)
+	if err == nil && choice == 0 {
+		err = errQuit
+	}
+	return choice, err
+}
+
+// announcedPlayer prints the moves and choices of the player on the console.
+type announcedPlayer struct {
+	game.ColorChooser
+	name string
+}
+
+func (p *announcedPlayer) NextMove(ctx context.Context, g *game.Game) (
+	board.Position, error) {
+	fmt.Print(turnString(g), " - ", p.name, "'s turn: ")
+	pos, err := p.ColorChooser.NextMove(ctx, g)
+	if err != nil {
+		fmt.Println()
+		return board.InvalidPosition, err
+	}
+	fmt.Println(pos)
+	return pos, nil
+}
+
+func (p *announcedPlayer) ChooseColor(ctx context.Context, g *game.Game) (
+	game.ColorChoice, error) {
+	fmt.Print(turnString(g), " - ", p.name, "'s choice: ")
+	choice, err := p.ColorChooser.ChooseColor(ctx, g)
+	if err != nil {
+		fmt.Println()
+		return 0, err
+	}
+	fmt.Println(choice)
+	return choice, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donyori/gorecover"
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/format"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/piskvork"
+)
+
+var protocolFlag = flag.String("protocol", "",
+	`front-end protocol, "piskvork" for Gomocup managers, `+
+		`or empty for the console`)
+
+var sgfFlag = flag.String("sgf", "",
+	"SGF file of a position, to print the AI's move on it and exit")
+
+var matchFlag = flag.String("match", "",
+	"JSON file of the settings of a match between two AIs, to play it and exit")
+
+var buildBookFlag = flag.String("buildbook", "",
+	"text file to write the opening book built from the game record files "+
+		"given as arguments, and exit")
+
+var blackFlag = flag.String("black", "",
+	`player of Black(the first player under swap openings), `+
+		`"user", "ai" or "random", or empty to follow the AI piece in settings`)
+
+var whiteFlag = flag.String("white", "",
+	`player of White(the second player under swap openings), `+
+		`"user", "ai" or "random", or empty to follow the AI piece in settings`)
+
+func main() {
+	flag.Parse()
+	err := gorecover.Recover(func() {
+		err := body()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+}
+
+// For debug.
+/*
+func main() {
+	err := body()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+}*/
+
+func body() error {
+	settings, err := LoadSettings()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if settings == nil {
+		settings = NewSettings()
+		err = StoreSettings(settings)
+		if err != nil {
+			// Just warning but not exit.
+			fmt.Fprintln(os.Stderr, "Try to store settings to", SettingsPath,
+				"but failed. Error:", err)
+		}
+	}
+
+	if settings.Io != nil {
+		bpSettings = settings.Io.BoardPrint
+		hintTimeLimit = settings.Io.HintTimeLimit
+	}
+
+	switch *protocolFlag {
+	case "":
+	case "piskvork":
+		return piskvork.Run(os.Stdin, os.Stdout, &settings.Settings)
+	default:
+		return fmt.Errorf("protocol %q is unknown", *protocolFlag)
+	}
+
+	if *sgfFlag != "" {
+		return printAiMoveOnSgf(*sgfFlag, settings)
+	}
+	if *matchFlag != "" {
+		return runMatch(*matchFlag, settings)
+	}
+	if *buildBookFlag != "" {
+		return buildBook(*buildBookFlag, flag.Args(), settings)
+	}
+
+	kinds, err := playerKinds(settings.Ai.AiPiece)
+	if err != nil {
+		return err
+	}
+	// Players other than the user are treated as AI by the game.
+	settings.Ai.AiPiece = 0
+	for i, color := range [...]board.Piece{board.Black, board.White} {
+		if kinds[i] != userPlayer {
+			settings.Ai.AiPiece |= color
+		}
+	}
+
+	g, err := game.NewGame(&settings.Settings)
+	if err != nil {
+		return err
+	}
+	runner := &game.Runner{Game: g}
+	defer func() {
+		// The game may be replaced by loading a game record.
+		runner.Game.TearDown()
+	}()
+	for i, kind := range kinds {
+		switch kind {
+		case userPlayer:
+			runner.Players[i] = &consolePlayer{runner: runner}
+		case aiPlayer:
+			runner.Players[i] = &announcedPlayer{
+				ColorChooser: new(game.AiPlayer),
+				name:         "AI",
+			}
+		case randomPlayer:
+			runner.Players[i] = &announcedPlayer{
+				ColorChooser: game.NewRandomPlayer(0),
+				name:         "Random player",
+			}
+		}
+	}
+	runner.OnMove = func(g *game.Game, pos board.Position) {
+		boardStr, err := format.PrintBoardToString(g.Board, bpSettings)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return
+		}
+		fmt.Println()
+		fmt.Println(boardStr)
+		fmt.Println()
+	}
+	runner.OnChoice = func(g *game.Game, choice game.ColorChoice) {
+		if choice != game.PlaceTwoMore &&
+			(g.AiPiece == board.Black || g.AiPiece == board.White) {
+			fmt.Println("AI plays", g.AiPiece)
+		}
+	}
+
+	boardStr, err := format.PrintBoardToString(g.Board, bpSettings)
+	if err != nil {
+		return err
+	}
+	PrintWelcome(nil)
+	fmt.Println()
+	fmt.Println(boardStr)
+	fmt.Println()
+
+	err = runner.Run(context.Background())
+	if err == errQuit {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fmt.Println("Game over. Winner:", runner.Game.Outcome)
+	return nil
+}
+
+// Kinds of players set by -black and -white.
+const (
+	userPlayer   string = "user"
+	aiPlayer     string = "ai"
+	randomPlayer string = "random"
+)
+
+// Return the kinds of the players of Black and White, by the flags,
+// or by aiPiece if the flags are empty.
+func playerKinds(aiPiece board.Piece) (kinds [2]string, err error) {
+	flags := [...]*string{blackFlag, whiteFlag}
+	for i, color := range [...]board.Piece{board.Black, board.White} {
+		kinds[i] = strings.ToLower(*flags[i])
+		switch kinds[i] {
+		case userPlayer, aiPlayer, randomPlayer:
+		case "":
+			kinds[i] = userPlayer
+			if aiPiece&color != 0 {
+				kinds[i] = aiPlayer
+			}
+		default:
+			return kinds, fmt.Errorf("player %q of %v is unknown",
+				*flags[i], color)
+		}
+	}
+	return
+}
+
+// Run the command input by user, and return the game to continue,
+// which is a new one if a game record is loaded.
+// Game records are in SGF if the file extension is ".sgf",
+// otherwise in JSON.
+// Failures on files are printed but not returned.
+func runCommand(g *game.Game, cmd *Command) (*game.Game, error) {
+	filename := cmd.Arg
+	if filename == "" {
+		filename = RecordPath
+	}
+	isSgf := strings.EqualFold(filepath.Ext(filename), ".sgf")
+	switch cmd.Name {
+	case "save":
+		f, err := os.Create(filename)
+		if err != nil {
+			fmt.Println("Cannot save the game:", err)
+			return g, nil
+		}
+		if isSgf {
+			err = format.SaveSgf(f, g)
+		} else {
+			err = g.SaveRecord(f)
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Println("Cannot save the game:", err)
+			return g, nil
+		}
+		fmt.Println("Game saved to", filename)
+	case "load":
+		f, err := os.Open(filename)
+		if err != nil {
+			fmt.Println("Cannot load the game:", err)
+			return g, nil
+		}
+		var loaded *game.Game
+		if isSgf {
+			loaded, err = format.LoadSgf(f, g.Settings)
+		} else {
+			loaded, err = game.LoadGame(f, g.Settings)
+		}
+		f.Close()
+		if err != nil {
+			fmt.Println("Cannot load the game:", err)
+			return g, nil
+		}
+		g.TearDown()
+		g = loaded
+		fmt.Println("Game loaded from", filename)
+		boardStr, err := format.PrintBoardToString(g.Board, bpSettings)
+		if err != nil {
+			return g, err
+		}
+		fmt.Println()
+		fmt.Println(boardStr)
+		fmt.Println()
+	default:
+		return g, fmt.Errorf("command %q is unknown", cmd.Name)
+	}
+	return g, nil
+}
+
+func printAiMoveOnSgf(filename string, settings *Settings) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	g, err := format.LoadSgf(f, &settings.Settings)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	defer g.TearDown()
+	if g.IsTerminal() {
+		return errors.New("game is over")
+	}
+	if g.Phase.IsColorChoice() {
+		return errors.New("game is waiting for color choice")
+	}
+	g.AiPiece = g.NextPlayer()
+	pos, err := g.PlaceByAi()
+	if err != nil {
+		return err
+	}
+	fmt.Println(pos)
+	return nil
+}
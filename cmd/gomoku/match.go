@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/match"
+)
+
+// Number of the games of a match if not set in the file.
+const defaultMatchNumGame int = 100
+
+// Play the match in a JSON file of match.Settings, and print the results.
+// The AI settings in the file override those in settings, and the rule,
+// board size and workers are taken from settings.
+func runMatch(filename string, settings *Settings) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	ms := &match.Settings{NumGame: defaultMatchNumGame}
+	for i := range ms.Ai {
+		ms.Ai[i] = copyAiSettings(settings.Ai)
+	}
+	err = json.Unmarshal(data, ms)
+	if err != nil {
+		return err
+	}
+	if ms.Sprt != nil {
+		lower, upper := ms.Sprt.Bounds()
+		fmt.Printf("SPRT: Elo0 %.1f, Elo1 %.1f, LLR bounds [%.2f, %.2f]\n",
+			ms.Sprt.Elo0, ms.Sprt.Elo1, lower, upper)
+	}
+	r, d, err := match.Run(context.Background(), &settings.Settings, ms,
+		func(gs *match.GameSummary, r *match.Result) {
+			var outcome string
+			switch gs.Winner {
+			case 0:
+				outcome = "draw"
+			case gs.FirstAiPiece:
+				outcome = "first AI wins"
+			default:
+				outcome = "second AI wins"
+			}
+			fmt.Printf("Game %d: first AI as %v, %s in %d moves. %s\n",
+				gs.Index+1, gs.FirstAiPiece, outcome, len(gs.History),
+				resultString(r, ms.Sprt))
+		})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Match over.", resultString(r, ms.Sprt))
+	if ms.Sprt != nil {
+		fmt.Println("SPRT:", d)
+	}
+	return nil
+}
+
+func resultString(r *match.Result, sprt *match.SprtSettings) string {
+	diff, margin := r.Elo()
+	s := fmt.Sprintf("W/L/D: %d/%d/%d, Elo: %.1f ± %.1f", r.Wins, r.Losses,
+		r.Draws, diff, margin)
+	if sprt != nil {
+		s += fmt.Sprintf(", LLR: %.2f", r.Llr(sprt))
+	}
+	return s
+}
+
+// Return a copy of ai, including the settings it points to.
+func copyAiSettings(ai *game.AiSettings) *game.AiSettings {
+	if ai == nil {
+		ai = game.NewSettings().Ai
+	}
+	c := *ai
+	if ai.AlphaBeta != nil {
+		ab := *ai.AlphaBeta
+		c.AlphaBeta = &ab
+	}
+	if ai.Solver != nil {
+		s := *ai.Solver
+		c.Solver = &s
+	}
+	if ai.Book != nil {
+		b := *ai.Book
+		c.Book = &b
+	}
+	return &c
+}
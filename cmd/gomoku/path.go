@@ -5,7 +5,7 @@ import (
 	"path/filepath"
 )
 
-var HomeDir, ExePath, SettingsPath string
+var HomeDir, ExePath, SettingsPath, RecordPath string
 
 func init() {
 	var err error
@@ -22,4 +22,5 @@ func init() {
 	}
 	HomeDir = filepath.Dir(ExePath)
 	SettingsPath = filepath.Join(HomeDir, "settings.json")
+	RecordPath = filepath.Join(HomeDir, "game_record.json")
 }
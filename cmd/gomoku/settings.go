@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/donyori/ucashw_gt_gomoku/format"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+)
+
+// Settings of the command, in the same JSON shape as before the split.
+type Settings struct {
+	game.Settings
+	Io *format.IoSettings `json:"io,omitempty"`
+}
+
+func NewSettings() *Settings {
+	return &Settings{
+		Settings: *game.NewSettings(),
+		Io:       format.NewIoSettings(),
+	}
+}
+
+func LoadSettings() (*Settings, error) {
+	data, err := ioutil.ReadFile(SettingsPath)
+	if err != nil {
+		return nil, err
+	}
+	settings := NewSettings()
+	err = json.Unmarshal(data, settings)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func StoreSettings(settings *Settings) error {
+	if settings == nil {
+		return errors.New("settings is nil")
+	}
+	data, err := json.MarshalIndent(settings, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(SettingsPath, data, 0666)
+}
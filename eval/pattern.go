@@ -0,0 +1,171 @@
+// Package eval provides heuristic evaluation of gomoku positions
+// by the patterns of stones.
+package eval
+
+import (
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// Score of a five, i.e. a win.
+const FiveScore int = 100000
+
+// Score of a four on a line by ScoreMove.
+// Moves scoring less don't make fours.
+const FourScore int = 1000
+
+// Scores of the patterns of a player on a line by ScoreMove, by the most
+// stones of the player in a window of five positions (up to 4), and
+// the number of windows with that many stones (up to 2).
+// Windows with any stone of the opponent don't count, so the patterns in
+// two or more windows are open, e.g. open fours and open threes, including
+// the split ones like "X_XX", and those in one window are not, e.g. fours
+// like "XX_XX".
+var patternScores = [5][3]int{
+	{0, 0, 0},
+	{0, 1, 10},
+	{0, 10, 100},
+	{0, 100, 1000},
+	{0, FourScore, 10000},
+}
+
+// Scores of a window of five positions by Evaluate, by the number of stones
+// of a player in it, if there is no stone of the opponent.
+var windowScores = [6]int{0, 1, 10, 100, 1000, FiveScore}
+
+// Return the score of the patterns made by placing piece at pos,
+// which should be empty. b is not modified.
+// Each line through pos scores by the windows of five positions on it
+// including pos, see patternScores.
+// Overlines score as fives only if they win by rule, and the windows next to
+// the other stones of piece don't count if overlines don't win.
+// Return 0 if pos is not empty or piece is neither Black nor White.
+func ScoreMove(rule rules.Rule, b *board.Board, pos board.Position,
+	piece board.Piece) int {
+	if (piece != board.Black && piece != board.White) || b.Get(pos) != 0 {
+		return 0
+	}
+	winCond := rule.WinCondition(piece)
+	var score int
+	for _, dir := range board.LineDirections {
+		cells := cellsAround(b, pos, dir)
+		cells[lineRadius] = piece
+		score += scoreLine(&cells, piece, winCond)
+	}
+	return score
+}
+
+func scoreLine(cells *lineCells, piece board.Piece,
+	winCond rules.WinCondition) int {
+	var most, count int
+	for k := -4; k <= 0; k++ {
+		n := countWindow(cells, k, piece, winCond)
+		if n > most {
+			most, count = n, 1
+		} else if n > 0 && n == most {
+			count++
+		}
+	}
+	if most >= 5 {
+		return FiveScore
+	}
+	if count > 2 {
+		count = 2
+	}
+	return patternScores[most][count]
+}
+
+// Return true if placing piece at pos, which should be empty, makes a four
+// or a five on any line through pos, i.e. a window of five positions with
+// four or more stones of piece, checked for overlines as in ScoreMove.
+// b is not modified.
+func MakesFour(rule rules.Rule, b *board.Board, pos board.Position,
+	piece board.Piece) bool {
+	if (piece != board.Black && piece != board.White) || b.Get(pos) != 0 {
+		return false
+	}
+	winCond := rule.WinCondition(piece)
+	for _, dir := range board.LineDirections {
+		cells := cellsAround(b, pos, dir)
+		cells[lineRadius] = piece
+		for k := -4; k <= 0; k++ {
+			if countWindow(&cells, k, piece, winCond) >= 4 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Maximum distance of the positions in lineCells from the center.
+const lineRadius int = 5
+
+// Pieces on a line, from the -lineRadius-th to the lineRadius-th positions
+// from the center, InvalidPiece for those outside the board.
+type lineCells [lineRadius*2 + 1]board.Piece
+
+// Return the pieces around pos along dir. Each position is read only once,
+// as the windows overlap.
+func cellsAround(b *board.Board, pos board.Position,
+	dir board.Direction) lineCells {
+	var cells lineCells
+	for k := -lineRadius; k <= lineRadius; k++ {
+		cells[k+lineRadius] = b.Get(b.PositionAlong(pos, dir, k))
+	}
+	return cells
+}
+
+// Return the number of the stones of piece in the window of five positions
+// starting from the k-th position of cells, or -1 if the window is outside
+// the board, has any stone of the opponent, or is next to a stone of piece
+// and overlines don't win by winCond. k should be in [-4, 0].
+func countWindow(cells *lineCells, k int, piece board.Piece,
+	winCond rules.WinCondition) int {
+	i := k + lineRadius
+	var n int
+	for _, p := range cells[i : i+5] {
+		switch p {
+		case piece:
+			n++
+		case 0:
+		default:
+			return -1
+		}
+	}
+	if winCond != rules.FiveOrMore &&
+		(cells[i-1] == piece || cells[i+5] == piece) {
+		return -1
+	}
+	return n
+}
+
+// Return the score of the patterns of piece on b minus that of the opponent.
+// Each window of five positions on a line with stones of only one player
+// scores by windowScores, so the open patterns, which are in more windows,
+// score more, and split patterns like "X_XX" score as the contiguous ones.
+// Windows are checked for overlines as in ScoreMove.
+// Return 0 if piece is neither Black nor White.
+func Evaluate(rule rules.Rule, b *board.Board, piece board.Piece) int {
+	var opponent board.Piece
+	switch piece {
+	case board.Black:
+		opponent = board.White
+	case board.White:
+		opponent = board.Black
+	default:
+		return 0
+	}
+	ownCond, otherCond := rule.WinCondition(piece), rule.WinCondition(opponent)
+	var score int
+	for _, pos := range board.GetAllPositions(b.Size()) {
+		for _, dir := range board.LineDirections {
+			cells := cellsAround(b, pos, dir)
+			if n := countWindow(&cells, 0, piece, ownCond); n > 0 {
+				score += windowScores[n]
+			} else if n = countWindow(&cells, 0, opponent, otherCond); n > 0 {
+				score -= windowScores[n]
+			}
+		}
+	}
+	return score
+}
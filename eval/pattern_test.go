@@ -0,0 +1,218 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestScoreMove(t *testing.T) {
+	cases := []struct {
+		black, white []string
+		pos          string
+		want         int
+	}{
+		{nil, nil, "h8", patternScores[1][2] * 4},
+		{[]string{"h8", "i8", "j8", "k8"}, nil, "g8",
+			FiveScore + patternScores[1][2]*3},
+		{[]string{"h8", "i8", "j8"}, nil, "g8",
+			patternScores[4][2] + patternScores[1][2]*3},
+		{[]string{"h8", "i8", "j8"}, []string{"f8"}, "g8",
+			patternScores[4][1] + patternScores[1][2]*3},
+		{[]string{"h8", "i8"}, []string{"f8", "j8"}, "g8",
+			patternScores[1][2] * 3},
+		{nil, nil, "a1", patternScores[1][1] * 3},
+		// Split patterns.
+		{[]string{"h8", "i8", "l8"}, nil, "k8",
+			patternScores[4][1] + patternScores[1][2]*3},
+		{[]string{"h8", "i8"}, nil, "k8",
+			patternScores[3][2] + patternScores[1][2]*3},
+		{[]string{"h8", "i8"}, []string{"g8"}, "k8",
+			patternScores[3][1] + patternScores[1][2]*3},
+		{[]string{"h8", "j8"}, []string{"f8"}, "i8",
+			patternScores[3][2] + patternScores[1][2]*3},
+	}
+	for _, c := range cases {
+		b, err := board.NewBoard(board.DefaultBoardSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeTestStones(t, b, c.black, board.Black)
+		placeTestStones(t, b, c.white, board.White)
+		pos, err := board.ParsePosition(c.pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		score := ScoreMove(rules.StandardGomoku, b, pos, board.Black)
+		if score != c.want {
+			t.Errorf("black %v, white %v, ScoreMove(%v) = %d, want %d",
+				c.black, c.white, pos, score, c.want)
+		}
+	}
+}
+
+func TestScoreMoveOccupied(t *testing.T) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeTestStones(t, b, []string{"h8"}, board.White)
+	pos, err := board.ParsePosition("h8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	score := ScoreMove(rules.StandardGomoku, b, pos, board.Black)
+	if score != 0 {
+		t.Errorf("ScoreMove on a stone = %d, want 0", score)
+	}
+}
+
+func TestScoreMoveOverline(t *testing.T) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeTestStones(t, b, []string{"h8", "i8", "j8", "k8", "m8"}, board.Black)
+	pos, err := board.ParsePosition("l8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		rule rules.Rule
+		want int
+	}{
+		{rules.StandardGomoku, patternScores[1][2] * 3},
+		{rules.Renju, patternScores[1][2] * 3},
+		{rules.FreestyleGomoku, FiveScore + patternScores[1][2]*3},
+	}
+	for _, c := range cases {
+		if score := ScoreMove(c.rule, b, pos, board.Black); score != c.want {
+			t.Errorf("%v: ScoreMove(%v) = %d, want %d", c.rule, pos, score,
+				c.want)
+		}
+	}
+	b.Set(pos, board.Black)
+	if score := Evaluate(rules.StandardGomoku, b, board.Black); score >=
+		FiveScore {
+		t.Errorf("Evaluate of an overline = %d, want less than a five", score)
+	}
+}
+
+// The scores of overlines under exact-five rules, where the windows next to
+// a stone of the player don't count, as computed before windows were read
+// from lines.
+func TestScoreMoveOverlineExact(t *testing.T) {
+	cases := []struct {
+		black, white []string
+		moves        []string
+		scores       []int
+		evaluation   int
+	}{
+		{
+			[]string{"h8", "i8", "j8", "k8", "m8"}, nil,
+			[]string{"l8", "g8", "n8"}, []int{30, 100030, 30}, 1071,
+		},
+		{
+			[]string{"h8", "i8", "j8", "l8"}, []string{"e8"},
+			[]string{"g8", "f8", "k8", "m8"}, []int{1030, 1030, 100030, 30},
+			1140,
+		},
+		{
+			[]string{"h8", "i8", "k8", "l8", "n8"}, []string{"o8"},
+			[]string{"j8", "g8", "m8"}, []int{100030, 130, 30}, 1070,
+		},
+		{
+			[]string{"d4", "e5", "f6", "h8", "i9"}, []string{"j10"},
+			[]string{"g7", "c3"}, []int{30, 1021}, 155,
+		},
+	}
+	for _, c := range cases {
+		b, err := board.NewBoard(board.DefaultBoardSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeTestStones(t, b, c.black, board.Black)
+		placeTestStones(t, b, c.white, board.White)
+		for i, move := range c.moves {
+			pos, err := board.ParsePosition(move)
+			if err != nil {
+				t.Fatal(err)
+			}
+			score := ScoreMove(rules.StandardGomoku, b, pos, board.Black)
+			if score != c.scores[i] {
+				t.Errorf("%v: ScoreMove(%v) = %d, want %d", c.black, pos, score,
+					c.scores[i])
+			}
+		}
+		score := Evaluate(rules.StandardGomoku, b, board.Black)
+		if score != c.evaluation {
+			t.Errorf("%v: Evaluate = %d, want %d", c.black, score, c.evaluation)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	cases := []struct {
+		name         string
+		black, white []string
+		rule         rules.Rule
+		min, max     int
+	}{
+		{"empty", nil, nil, rules.StandardGomoku, 0, 0},
+		// 5 windows on each line.
+		{"one stone", []string{"h8"}, nil, rules.StandardGomoku,
+			windowScores[1] * 20, windowScores[1] * 20},
+		{"five", []string{"h8", "i8", "j8", "k8", "l8"}, nil,
+			rules.StandardGomoku, FiveScore, FiveScore * 2},
+		{"overline", []string{"h8", "i8", "j8", "k8", "l8", "m8"}, nil,
+			rules.StandardGomoku, 0, FiveScore - 1},
+		{"overline, freestyle", []string{"h8", "i8", "j8", "k8", "l8", "m8"},
+			nil, rules.FreestyleGomoku, FiveScore * 2, FiveScore * 3},
+		{"split four", []string{"h8", "i8", "k8", "l8"}, nil,
+			rules.StandardGomoku, windowScores[4], windowScores[4] * 2},
+		{"open split three", []string{"h8", "i8", "k8"}, []string{"a1"},
+			rules.StandardGomoku, windowScores[3] * 2, windowScores[4]},
+	}
+	for _, c := range cases {
+		b, err := board.NewBoard(board.DefaultBoardSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeTestStones(t, b, c.black, board.Black)
+		placeTestStones(t, b, c.white, board.White)
+		score := Evaluate(c.rule, b, board.Black)
+		if score < c.min || score > c.max {
+			t.Errorf("%s: Evaluate = %d, want in [%d, %d]", c.name, score,
+				c.min, c.max)
+		}
+		if s := Evaluate(c.rule, b, board.White); s != -score {
+			t.Errorf("%s: Evaluate for White = %d, want %d", c.name, s, -score)
+		}
+	}
+	// The open three outscores the same three blocked on one end.
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeTestStones(t, b, []string{"h8", "i8", "k8"}, board.Black)
+	placeTestStones(t, b, []string{"a1"}, board.White)
+	open := Evaluate(rules.StandardGomoku, b, board.Black)
+	placeTestStones(t, b, []string{"a1"}, 0)
+	placeTestStones(t, b, []string{"g8"}, board.White)
+	if blocked := Evaluate(rules.StandardGomoku, b, board.Black); blocked >=
+		open {
+		t.Errorf("blocked three: %d, open three: %d", blocked, open)
+	}
+}
+
+func placeTestStones(tb testing.TB, b *board.Board, stones []string,
+	piece board.Piece) {
+	for _, s := range stones {
+		p, err := board.ParsePosition(s)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		b.Set(p, piece)
+	}
+}
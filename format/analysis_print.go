@@ -0,0 +1,38 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+)
+
+// Return a table of the first numMove candidate moves in the analysis,
+// one move per line. If numMove is not positive, all moves are included.
+func PrintAnalysisToString(a *game.Analysis, numMove int) string {
+	if a == nil {
+		return ""
+	}
+	moves := a.Moves
+	if numMove > 0 && numMove < len(moves) {
+		moves = moves[:numMove]
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Simulations: %d\n", a.NumSim)
+	fmt.Fprintf(&sb, "%-5s %10s %11s %8s  %s\n", "Move", "Sims", "Win", "UCT",
+		"Principal variation")
+	for _, m := range moves {
+		win := fmt.Sprintf("%.1f%%", m.WinRate*100.)
+		if m.Proof != mcts.NotProven {
+			win = m.Proof.String()
+		}
+		pv := make([]string, len(m.PrincipalVariation))
+		for i, pos := range m.PrincipalVariation {
+			pv[i] = pos.String()
+		}
+		fmt.Fprintf(&sb, "%-5v %10d %11s %8.3f  %s\n", m.Pos, m.NumSim, win,
+			m.Uct, strings.Join(pv, " "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,43 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+)
+
+func TestPrintAnalysisToString(t *testing.T) {
+	h8, _ := board.ParsePosition("H8")
+	h9, _ := board.ParsePosition("H9")
+	j10, _ := board.ParsePosition("J10")
+	a := &game.Analysis{
+		NumSim: 30,
+		Moves: []game.MoveAnalysis{
+			{Pos: h8, NumSim: 20, WinRate: .75, Uct: 1.5,
+				PrincipalVariation: []board.Position{h8, h9}},
+			{Pos: h9, NumSim: 9, Proof: mcts.ProvenLoss,
+				PrincipalVariation: []board.Position{h9}},
+			{Pos: j10, NumSim: 1, PrincipalVariation: []board.Position{j10}},
+		},
+	}
+	s := PrintAnalysisToString(a, 2)
+	t.Log("\n" + s)
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("number of lines: %d, want 4", len(lines))
+	}
+	if !strings.HasPrefix(lines[2], "H8") ||
+		!strings.Contains(lines[2], "75.0%") ||
+		!strings.HasSuffix(lines[2], "H8 H9") {
+		t.Errorf("line of H8: %q", lines[2])
+	}
+	if !strings.Contains(lines[3], mcts.ProvenLoss.String()) {
+		t.Errorf("line of H9: %q", lines[3])
+	}
+	if s = PrintAnalysisToString(a, 0); strings.Count(s, "\n") != 5 {
+		t.Errorf("all moves:\n%s", s)
+	}
+}
@@ -0,0 +1,119 @@
+// Package format provides text formats of boards and game records.
+package format
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+type BoardPrintSettings struct {
+	EmptyChar          string `json:"empty_char,omitempty"`
+	BlackChar          string `json:"black_char,omitempty"`
+	WhiteChar          string `json:"white_char,omitempty"`
+	DoesShowLineNumber bool   `json:"does_show_line_number,omitempty"`
+}
+
+type IoSettings struct {
+	BoardPrint *BoardPrintSettings `json:"board_print,omitempty"`
+	// Time to search for the hint command of the console.
+	// Not positive for the limits of the AI's search.
+	HintTimeLimit time.Duration `json:"hint_time_limit,omitempty"`
+}
+
+func NewIoSettings() *IoSettings {
+	return &IoSettings{
+		BoardPrint: &BoardPrintSettings{
+			EmptyChar:          ".",
+			BlackChar:          "x",
+			WhiteChar:          "o",
+			DoesShowLineNumber: true,
+		},
+		HintTimeLimit: time.Second * 3,
+	}
+}
+
+func PrintBoardToString(b *board.Board, bpSettings *BoardPrintSettings) (
+	string, error) {
+	if b == nil {
+		return "", errors.New("board is nil")
+	}
+	boardSize := b.Size()
+	var ec, bc, wc string
+	var sln bool
+	if bpSettings != nil {
+		ec = bpSettings.EmptyChar
+		bc = bpSettings.BlackChar
+		wc = bpSettings.WhiteChar
+		sln = bpSettings.DoesShowLineNumber
+	} else {
+		ec = "."
+		bc = "x"
+		wc = "o"
+		sln = true
+	}
+	numW := b.NumStone() / 2
+	numB := b.NumStone() - numW
+	numPos := boardSize * boardSize
+	capacity := (numPos-numB-numW)*len(ec) + numB*len(bc) + numW*len(wc) +
+		numPos - 1 // Including '\n' and ' ' per line.
+	if sln {
+		capacity += boardSize * 3 // Columns and space per row.
+		// Rows:
+		if boardSize >= 10 && boardSize < 100 {
+			capacity += 9 + (boardSize-9)*2
+		} else if boardSize < 10 {
+			capacity += boardSize
+		} else {
+			capacity += boardSize * 3
+		}
+	}
+	var builder strings.Builder
+	builder.Grow(capacity)
+	// fmt.Println("cap", builder.Cap())
+	if sln {
+		for i := 0; i < boardSize; i++ {
+			builder.WriteRune('A' + rune(i))
+			if i < boardSize-1 {
+				builder.WriteRune(' ')
+			} else {
+				builder.WriteRune('\n')
+			}
+		}
+	}
+	for y := 0; y < boardSize; y++ {
+		for x := 0; x < boardSize; x++ {
+			p, err := board.GetPosition(x, y)
+			if err != nil {
+				return "", err
+			}
+			piece := b.Get(p)
+			switch piece {
+			case 0:
+				builder.WriteString(ec)
+			case board.Black:
+				builder.WriteString(bc)
+			case board.White:
+				builder.WriteString(wc)
+			default:
+				return "", fmt.Errorf("unknown piece on board: %d", piece)
+			}
+			if x < boardSize-1 {
+				builder.WriteRune(' ')
+			}
+		}
+		if sln {
+			builder.WriteRune(' ')
+			builder.WriteString(strconv.Itoa(y + 1))
+		}
+		if y < boardSize-1 {
+			builder.WriteRune('\n')
+		}
+	}
+	// fmt.Println("len", builder.Len())
+	return builder.String(), nil
+}
@@ -0,0 +1,50 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+func TestPrintBoardToString(t *testing.T) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mid, err := board.ParsePosition("H8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Set(mid, board.Black)
+	p, err := mid.Move(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Set(p, board.White)
+	p, err = mid.Move(-1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Set(p, board.Black)
+	s, err := PrintBoardToString(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("\n" + s)
+	t.Log("len", len(s))
+}
+
+func TestPrintBoardToStringWithBoardSize(t *testing.T) {
+	for _, size := range []int{board.MinBoardSize, 20, board.MaxBoardSize} {
+		b, err := board.NewBoard(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Set(board.GetCenterPosition(size), board.Black)
+		s, err := PrintBoardToString(b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log("\n" + s)
+	}
+}
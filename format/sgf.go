@@ -0,0 +1,339 @@
+package format
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// Smart Game Format(SGF), see https://www.red-bean.com/sgf/
+// Gomoku and Renju games are GM[4].
+
+const sgfGameGomoku string = "4"
+
+// Properties of an SGF node, from property identifiers to values.
+type sgfNode map[string][]string
+
+// Return the SGF coordinates of pos, e.g. "hh" for H8.
+func SgfCoord(pos board.Position) string {
+	if pos.IsOutOfRange() {
+		return ""
+	}
+	return string([]byte{byte('a' + pos.X()), byte('a' + pos.Y())})
+}
+
+func ParseSgfCoord(s string) (board.Position, error) {
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'z' || s[1] < 'a' || s[1] > 'z' {
+		return board.InvalidPosition, board.NewUnknownPositionError(s)
+	}
+	return board.GetPosition(int(s[0]-'a'), int(s[1]-'a'))
+}
+
+// Write the record in SGF.
+// Color choices of opening protocols are not kept in SGF.
+func WriteSgf(w io.Writer, gr *game.GameRecord) error {
+	if w == nil {
+		return errors.New("w is nil")
+	}
+	if gr == nil {
+		return errors.New("game record is nil")
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "(;FF[4]GM[%s]CA[UTF-8]AP[ucashw_gt_gomoku]SZ[%d]RU[%s]",
+		sgfGameGomoku, gr.BoardSize, sgfEscape(gr.Rule.String()))
+	if gr.Players.Black != "" {
+		fmt.Fprintf(bw, "PB[%s]", sgfEscape(gr.Players.Black))
+	}
+	if gr.Players.White != "" {
+		fmt.Fprintf(bw, "PW[%s]", sgfEscape(gr.Players.White))
+	}
+	if !gr.StartTime.IsZero() {
+		fmt.Fprintf(bw, "DT[%s]", gr.StartTime.Format("2006-01-02"))
+	}
+	switch gr.Result {
+	case "Black":
+		bw.WriteString("RE[B+]")
+	case "White":
+		bw.WriteString("RE[W+]")
+	case "Draw":
+		bw.WriteString("RE[0]")
+	}
+	for i, move := range gr.Moves {
+		pos, err := board.ParsePosition(move)
+		if err != nil {
+			return fmt.Errorf("move %d: %v", i+1, err)
+		}
+		if i%10 == 0 {
+			bw.WriteByte('\n')
+		}
+		color := 'B'
+		if board.PieceOfStep(uint(i+1)) == board.White {
+			color = 'W'
+		}
+		fmt.Fprintf(bw, ";%c[%s]", color, SgfCoord(pos))
+	}
+	bw.WriteString(")\n")
+	return bw.Flush()
+}
+
+// Read the main line of the first game in SGF from r.
+// The rule of the returned record is 0 if it's not specified or unknown.
+// Setup stones(AB and AW) in the root node are taken as the first moves,
+// in which case the numbers of black and white stones must be balanced.
+func ReadSgf(r io.Reader) (*game.GameRecord, error) {
+	if r == nil {
+		return nil, errors.New("r is nil")
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := parseSgf(string(data))
+	if err != nil {
+		return nil, err
+	}
+	root := nodes[0]
+	if gm := sgfValue(root, "GM"); gm != "" && gm != sgfGameGomoku {
+		return nil, fmt.Errorf("SGF game type GM[%s] is not gomoku", gm)
+	}
+	gr := &game.GameRecord{
+		Version:   game.GameRecordVersion,
+		Rule:      sgfRule(sgfValue(root, "RU")),
+		BoardSize: board.DefaultBoardSize,
+		Moves:     []string{},
+		Players: game.PlayersRecord{
+			Black: sgfValue(root, "PB"),
+			White: sgfValue(root, "PW"),
+		},
+	}
+	if sz := sgfValue(root, "SZ"); sz != "" {
+		if i := strings.IndexByte(sz, ':'); i >= 0 {
+			if sz[:i] != sz[i+1:] {
+				return nil, fmt.Errorf("SGF board size %s is not square", sz)
+			}
+			sz = sz[:i]
+		}
+		gr.BoardSize, err = strconv.Atoi(sz)
+		if err != nil {
+			return nil, fmt.Errorf("SGF board size %s is invalid", sz)
+		}
+	}
+	if dt := sgfValue(root, "DT"); len(dt) >= 10 {
+		// Ignore the date if it's in other formats.
+		gr.StartTime, _ = time.ParseInLocation("2006-01-02", dt[:10],
+			time.Local)
+	}
+	re := strings.ToUpper(sgfValue(root, "RE"))
+	switch {
+	case strings.HasPrefix(re, "B+"):
+		gr.Result = "Black"
+	case strings.HasPrefix(re, "W+"):
+		gr.Result = "White"
+	case re == "0" || re == "DRAW":
+		gr.Result = "Draw"
+	}
+
+	ab, aw := root["AB"], root["AW"]
+	if len(ab) != len(aw) && len(ab) != len(aw)+1 {
+		return nil, fmt.Errorf(
+			"numbers of setup stones are unbalanced, black: %d, white: %d",
+			len(ab), len(aw))
+	}
+	for i := range ab {
+		setup := []string{ab[i]}
+		if i < len(aw) {
+			setup = append(setup, aw[i])
+		}
+		for _, s := range setup {
+			pos, err := ParseSgfCoord(s)
+			if err != nil {
+				return nil, err
+			}
+			gr.Moves = append(gr.Moves, pos.String())
+		}
+	}
+	for _, node := range nodes {
+		var color string
+		var piece board.Piece
+		if _, ok := node["B"]; ok {
+			color, piece = "B", board.Black
+			if _, ok = node["W"]; ok {
+				return nil, errors.New("SGF node has both B and W")
+			}
+		} else if _, ok = node["W"]; ok {
+			color, piece = "W", board.White
+		} else {
+			continue
+		}
+		step := uint(len(gr.Moves) + 1)
+		if board.PieceOfStep(step) != piece {
+			return nil, fmt.Errorf("SGF move %d is not %v", step,
+				board.PieceOfStep(step))
+		}
+		v := sgfValue(node, color)
+		if v == "" || v == "tt" {
+			return nil, fmt.Errorf("SGF move %d is a pass", step)
+		}
+		pos, err := ParseSgfCoord(v)
+		if err != nil {
+			return nil, fmt.Errorf("SGF move %d: %v", step, err)
+		}
+		gr.Moves = append(gr.Moves, pos.String())
+	}
+	return gr, nil
+}
+
+// Write the record of the game to w in SGF.
+func SaveSgf(w io.Writer, g *game.Game) error {
+	gr, err := g.Record()
+	if err != nil {
+		return err
+	}
+	return WriteSgf(w, gr)
+}
+
+// Read a game in SGF from r, and replay it to a new game.
+// If the rule is not specified in SGF, the rule in settings is used.
+// See game.LoadGame for other settings used.
+func LoadSgf(r io.Reader, settings *game.Settings) (*game.Game, error) {
+	gr, err := ReadSgf(r)
+	if err != nil {
+		return nil, err
+	}
+	if gr.Rule == 0 {
+		gr.Rule = rules.StandardGomoku
+		if settings != nil {
+			gr.Rule = settings.Rule
+		}
+	}
+	return gr.Replay(settings)
+}
+
+// Parse the main line of the first game tree in s, i.e. the first variation
+// at each branch. Return at least one node.
+func parseSgf(s string) ([]sgfNode, error) {
+	i := strings.IndexByte(s, '(')
+	if i < 0 {
+		return nil, errors.New("SGF game tree is not found")
+	}
+	var nodes []sgfNode
+	var node sgfNode
+	for i++; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '(':
+			// Enter the first variation. The main line ends before
+			// the other variations.
+			i++
+		case c == ')':
+			if len(nodes) == 0 {
+				return nil, errors.New("SGF game tree is empty")
+			}
+			return nodes, nil
+		case c == ';':
+			node = sgfNode{}
+			nodes = append(nodes, node)
+			i++
+		case c >= 'A' && c <= 'Z':
+			if node == nil {
+				return nil, errors.New("SGF property is outside nodes")
+			}
+			j := i
+			for j < len(s) && s[j] >= 'A' && s[j] <= 'Z' {
+				j++
+			}
+			ident := s[i:j]
+			n := len(node[ident])
+			for {
+				for j < len(s) && isSgfSpace(s[j]) {
+					j++
+				}
+				if j >= len(s) || s[j] != '[' {
+					break
+				}
+				v, k, err := sgfReadValue(s[j:])
+				if err != nil {
+					return nil, err
+				}
+				node[ident] = append(node[ident], v)
+				j += k
+			}
+			if len(node[ident]) == n {
+				return nil, fmt.Errorf("SGF property %s has no value", ident)
+			}
+			i = j
+		case isSgfSpace(c) || (c >= 'a' && c <= 'z'):
+			// Lower case letters in identifiers are from old SGF versions.
+			i++
+		default:
+			return nil, fmt.Errorf("SGF has unexpected character %q", c)
+		}
+	}
+	return nil, errors.New("SGF game tree is not closed")
+}
+
+// Read a property value starting with '[' in s.
+// Return the unescaped value and the number of bytes read.
+func sgfReadValue(s string) (value string, n int, err error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if i < len(s) && s[i] != '\n' && s[i] != '\r' {
+				b.WriteByte(s[i])
+			}
+		case ']':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("SGF property value is not closed")
+}
+
+func sgfValue(node sgfNode, ident string) string {
+	if vs := node[ident]; len(vs) > 0 {
+		return strings.TrimSpace(vs[0])
+	}
+	return ""
+}
+
+func sgfEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "]", `\]`)
+}
+
+func isSgfSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// Return 0 for unknown rules.
+func sgfRule(s string) rules.Rule {
+	if r := rules.ParseRule(s); r != 0 {
+		return r
+	}
+	switch strings.ToLower(s) {
+	case "gomoku", "standard":
+		return rules.StandardGomoku
+	case "freestyle", "free":
+		return rules.FreestyleGomoku
+	case "pro":
+		return rules.GomokuPro
+	case "swap":
+		return rules.GomokuSwap
+	case "swap2":
+		return rules.GomokuSwap2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,110 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestSgfCoord(t *testing.T) {
+	for _, s := range []string{"A1", "H8", "O15", "Z26"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := SgfCoord(pos)
+		p, err := ParseSgfCoord(c)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if p != pos {
+			t.Errorf("%s -> %s -> %v", s, c, p)
+		}
+	}
+	if c := SgfCoord(board.GetCenterPosition(board.DefaultBoardSize)); c != "hh" {
+		t.Errorf("center: %s, want hh", c)
+	}
+	for _, c := range []string{"", "a", "aaa", "A1", "h?"} {
+		if _, err := ParseSgfCoord(c); err == nil {
+			t.Errorf("no error for %q", c)
+		}
+	}
+}
+
+func TestSaveAndLoadSgf(t *testing.T) {
+	settings := game.NewSettings()
+	settings.Rule = rules.Renju
+	g, err := game.NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.TearDown()
+	for _, s := range []string{"h8", "j9", "g7", "j7", "j8"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = g.PlaceByUser(pos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err = SaveSgf(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("\n" + buf.String())
+	loaded, err := LoadSgf(&buf, game.NewSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.TearDown()
+	if loaded.Settings.Rule != rules.Renju {
+		t.Errorf("rule: %v, want Renju", loaded.Settings.Rule)
+	}
+	if len(loaded.History) != len(g.History) {
+		t.Fatalf("history: %v, want %v", loaded.History, g.History)
+	}
+	for i := range g.History {
+		if loaded.History[i] != g.History[i] {
+			t.Fatalf("history: %v, want %v", loaded.History, g.History)
+		}
+	}
+}
+
+func TestReadSgf(t *testing.T) {
+	sgf := `(;GM[4]FF[4]SZ[15]PB[Alice \] A]RE[W+]C[comment (with) ;parens]
+		AB[hh][ii]AW[hi]
+		;W[ih]
+		(;B[jj];W[gg])
+		(;B[aa])
+	)`
+	gr, err := ReadSgf(strings.NewReader(sgf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"H8", "H9", "I9", "I8", "J10", "G7"}
+	if strings.Join(gr.Moves, " ") != strings.Join(want, " ") {
+		t.Errorf("moves: %v, want %v", gr.Moves, want)
+	}
+	if gr.Rule != 0 || gr.BoardSize != 15 || gr.Result != "White" ||
+		gr.Players.Black != "Alice ] A" {
+		t.Errorf("rule: %v, board size: %d, result: %s, black: %q", gr.Rule,
+			gr.BoardSize, gr.Result, gr.Players.Black)
+	}
+
+	for i, sgf := range []string{
+		"",
+		"(;GM[1]SZ[19];B[aa])",
+		"(;GM[4];B[hh];B[ii])",
+		"(;GM[4];B[hh];W[])",
+		"(;GM[4];B[hh]",
+		"(;GM[4]SZ[15:13])",
+	} {
+		if _, err = ReadSgf(strings.NewReader(sgf)); err == nil {
+			t.Errorf("no error for SGF %d", i)
+		}
+	}
+}
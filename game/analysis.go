@@ -0,0 +1,77 @@
+package game
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+)
+
+// Maximum length of the principal variations in an analysis.
+const maxPrincipalVariationLen int = 10
+
+// Analysis of the current position of a game by the search.
+type Analysis struct {
+	// Number of simulations of the current position.
+	NumSim uint64 `json:"num_sim"`
+	// Candidate moves, in descending order of NumSim.
+	Moves []MoveAnalysis `json:"moves"`
+}
+
+type MoveAnalysis struct {
+	Pos    board.Position `json:"pos"`
+	NumSim uint64         `json:"num_sim"`
+	// Win rate of the player to move by this move.
+	WinRate float64    `json:"win_rate"`
+	Uct     float64    `json:"uct"`
+	Proof   mcts.Proof `json:"proof,omitempty"`
+	// Moves expected to follow, by the most simulated children,
+	// starting with Pos.
+	PrincipalVariation []board.Position `json:"principal_variation"`
+}
+
+// Search the current position for budget without placing a stone,
+// and return the statistics of the candidate moves.
+// If budget is not positive, the limits in Settings.Ai are used.
+// The tree grown by the search is kept for the following moves.
+func (g *Game) Analyze(budget time.Duration) (*Analysis, error) {
+	err := g.checkPlace()
+	if err != nil {
+		return nil, err
+	}
+	err = g.StopPondering()
+	if err != nil {
+		return nil, err
+	}
+	if budget > 0 {
+		_, err = g.mctRoot.MonteCarloTreeSearchFor(context.Background(),
+			budget)
+	} else {
+		_, err = g.mctRoot.MonteCarloTreeSearch()
+	}
+	if err != nil {
+		return nil, err
+	}
+	a := &Analysis{NumSim: g.mctRoot.NumSim}
+	for node := g.mctRoot.LastChild; node != nil; node = node.PrevSibling {
+		ma := MoveAnalysis{
+			Pos:     node.Pos,
+			NumSim:  node.NumSim,
+			WinRate: node.WinRate(),
+			Uct:     node.Uct(),
+			Proof:   node.Proof(),
+		}
+		n := node
+		for n != nil && len(ma.PrincipalVariation) < maxPrincipalVariationLen {
+			ma.PrincipalVariation = append(ma.PrincipalVariation, n.Pos)
+			n = n.GetBestNumSimChild()
+		}
+		a.Moves = append(a.Moves, ma)
+	}
+	sort.SliceStable(a.Moves, func(i, j int) bool {
+		return a.Moves[i].NumSim > a.Moves[j].NumSim
+	})
+	return a, nil
+}
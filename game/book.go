@@ -0,0 +1,108 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/book"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// Load the opening book by settings.Ai.Book.
+// Return nil if the book is disabled.
+func loadBook(settings *Settings) (*book.Book, error) {
+	bs := settings.Ai.Book
+	if bs == nil {
+		bs = book.NewSettings()
+	}
+	if bs.MaxStones <= 0 {
+		return nil, nil
+	}
+	if bs.File == "" {
+		return book.Default(settings.BoardSize)
+	}
+	f, err := os.Open(bs.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // Ignore error.
+	return book.Read(f, settings.BoardSize)
+}
+
+// Return the first legal book move for the player to move, and true,
+// or false if there is none. The book is only consulted in NormalPhase,
+// as it doesn't keep the openings balanced.
+func (g *Game) bookMove() (board.Position, bool) {
+	if g.openingBook == nil || g.Phase != NormalPhase {
+		return board.InvalidPosition, false
+	}
+	bs := g.Settings.Ai.Book
+	if bs == nil {
+		bs = book.NewSettings()
+	}
+	if g.Board.NumStone() > bs.MaxStones {
+		return board.InvalidPosition, false
+	}
+	for _, m := range g.openingBook.Lookup(g.Board) {
+		isLegal, _, err := rules.IsLegal(g.Settings.Rule, g.Board, g.Step()+1,
+			m.Pos)
+		if err == nil && isLegal {
+			return m.Pos, true
+		}
+	}
+	return board.InvalidPosition, false
+}
+
+// Add the moves of the winner of the record to bk, with weight 1, while
+// there are at most maxStones stones on the board. The moves of both
+// players are added for a draw, and none for an unfinished game.
+// The opening moves before the last color choice are skipped,
+// as they're meant to be balanced.
+func (gr *GameRecord) AddToBook(bk *book.Book, maxStones int) error {
+	if bk == nil {
+		return errors.New("bk is nil")
+	}
+	if gr.BoardSize != bk.BoardSize() {
+		return fmt.Errorf("board size %d is not the size of the book(%d)",
+			gr.BoardSize, bk.BoardSize())
+	}
+	var winner board.Piece
+	switch gr.Result {
+	case "":
+		return nil
+	case "Draw":
+		winner = board.Both
+	default:
+		winner = board.ParsePiece(gr.Result)
+		if winner != board.Black && winner != board.White {
+			return fmt.Errorf("result %q is unknown", gr.Result)
+		}
+	}
+	var start int
+	for _, c := range gr.Choices {
+		if int(c.Step) > start {
+			start = int(c.Step)
+		}
+	}
+	line := make([]board.Position, len(gr.Moves))
+	for i, move := range gr.Moves {
+		if i > maxStones {
+			break
+		}
+		pos, err := board.ParsePosition(move)
+		if err != nil {
+			return fmt.Errorf("move %d: %v", i+1, err)
+		}
+		line[i] = pos
+		if i < start || board.PieceOfStep(uint(i+1))&winner == 0 {
+			continue
+		}
+		err = bk.AddLine(line[:i+1], 1)
+		if err != nil {
+			return fmt.Errorf("move %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/book"
+)
+
+func TestGameRecordAddToBook(t *testing.T) {
+	bk, err := book.New(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []*GameRecord{
+		{BoardSize: 15, Moves: []string{"H8", "I9", "I8", "G8", "J8"},
+			Result: "Black"},
+		// I9 of the first record, rotated.
+		{BoardSize: 15, Moves: []string{"H8", "G9", "A1"}, Result: "White"},
+		{BoardSize: 15, Moves: []string{"H8", "H9"}},
+	}
+	for _, gr := range records {
+		err = gr.AddToBook(bk, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	err = bk.Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// H8 and I8 by Black of the first record, and G9 by White of the second.
+	if bk.Len() != 3 {
+		t.Errorf("book:\n%s", buf.String())
+	}
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Set(board.GetCenterPosition(board.DefaultBoardSize), board.Black)
+	moves := bk.Lookup(b)
+	if len(moves) != 1 || moves[0].Weight != 1 {
+		t.Errorf("moves after H8: %v", moves)
+	}
+	gr := &GameRecord{BoardSize: 13, Moves: []string{"G7"}, Result: "Black"}
+	if gr.AddToBook(bk, 2) == nil {
+		t.Error("no error for a record of another board size")
+	}
+}
@@ -0,0 +1,11 @@
+package game
+
+import "errors"
+
+var (
+	ErrTearDown              error = errors.New("game is already tear-down")
+	ErrTerminal              error = errors.New("game is terminal")
+	ErrWaitingForColorChoice error = errors.New("game is waiting for color choice")
+	ErrNotColorChoice        error = errors.New("it's not time to choose color")
+	ErrNotAiTurn             error = errors.New("it's not AI's turn")
+)
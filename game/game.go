@@ -0,0 +1,536 @@
+// Package game provides gomoku games between a user and the AI.
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/alphabeta"
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/book"
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+	"github.com/donyori/ucashw_gt_gomoku/solver"
+)
+
+// Maximum number of previous roots of the Monte Carlo tree kept for undo.
+const maxNumPrevRoots int = 8
+
+type Game struct {
+	Settings *Settings
+
+	History []board.Position
+	Board   *board.Board
+	Outcome board.Piece
+
+	Phase GamePhase
+	// The current color of AI. It's initialized by Settings.Ai.AiPiece and
+	// may be changed by color choices under swap openings.
+	AiPiece board.Piece
+	// Color choices made in the opening, in order.
+	Choices []ChoiceRecord
+	// When the game started.
+	StartTime time.Time
+
+	tree    *mcts.Tree
+	mctRoot *mcts.Node
+	// Nil if the opening book is disabled.
+	openingBook *book.Book
+	// Whether mctRoot is terminal, cached to be read safely while pondering.
+	isRootTerminal bool
+	// Previous roots of the tree, to reuse their subtrees on undo.
+	prevRoots []*mcts.Node
+	// Phase and AiPiece before each move in History, to restore them on undo.
+	undoStates []undoState
+
+	ponderStopChan chan struct{}
+	ponderDoneChan chan error
+}
+
+type undoState struct {
+	phase   GamePhase
+	aiPiece board.Piece
+}
+
+func NewGame(settings *Settings) (*Game, error) {
+	if settings == nil {
+		settings = NewSettings()
+	}
+	if settings.Ai == nil {
+		return nil, errors.New("settings.Ai is nil")
+	}
+	switch settings.Ai.Engine {
+	case "", MctsEngine, AlphaBetaEngine:
+	default:
+		return nil, fmt.Errorf("engine %q is unknown", settings.Ai.Engine)
+	}
+	b, err := board.NewBoard(settings.BoardSize)
+	if err != nil {
+		return nil, err
+	}
+	openingBook, err := loadBook(settings)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := mcts.NewTree(settings.Rule, b, &settings.Ai.Settings,
+		settings.Worker)
+	if err != nil {
+		return nil, err
+	}
+	root, err := tree.NewNode(0, board.InvalidPosition)
+	if err != nil {
+		tree.Close()
+		return nil, err
+	}
+	numPos := settings.BoardSize * settings.BoardSize
+	g := &Game{
+		Settings:    settings,
+		History:     make([]board.Position, 0, numPos),
+		Board:       b,
+		AiPiece:     settings.Ai.AiPiece,
+		StartTime:   time.Now(),
+		tree:        tree,
+		mctRoot:     root,
+		openingBook: openingBook,
+		undoStates:  make([]undoState, 0, numPos),
+	}
+	g.isRootTerminal = root.IsTerminal()
+	if settings.Rule.Opening() != rules.NoOpening {
+		g.Phase = OpeningPhase
+	}
+	return g, nil
+}
+
+func (g *Game) IsTearDown() bool {
+	return g == nil || g.mctRoot == nil
+}
+
+func (g *Game) TearDown() {
+	if g == nil {
+		return
+	}
+	// Ignore the error of pondering, as the game is over anyway.
+	g.StopPondering()
+	g.tree.Close()
+	g.mctRoot = nil
+	g.prevRoots = nil
+}
+
+func (g *Game) IsTerminal() bool {
+	return g.IsTearDown() || g.Outcome != 0 || g.isRootTerminal
+}
+
+func (g *Game) BoardSize() int {
+	return g.Settings.BoardSize
+}
+
+func (g *Game) Step() uint {
+	return uint(len(g.History))
+}
+
+func (g *Game) NextTurn() board.Piece {
+	if g.IsTerminal() {
+		return board.InvalidPiece
+	}
+	step := g.Step()
+	// Step is for current, return value is for next.
+	if step%2 == 0 {
+		return board.Black
+	} else {
+		return board.White
+	}
+}
+
+// Return the (tentative) color of the player who should act next.
+// During the opening, the first player is treated as Black and the second
+// player as White, no matter which color the stone to be placed is.
+// Otherwise, it's the same as NextTurn.
+func (g *Game) NextPlayer() board.Piece {
+	if g.IsTerminal() {
+		return board.InvalidPiece
+	}
+	return nextPlayerOf(g.Phase, g.Step())
+}
+
+func (g *Game) IsAiTurn() bool {
+	return g.NextPlayer()&g.AiPiece != 0
+}
+
+func (g *Game) PlaceByUser(pos board.Position) error {
+	err := g.checkPlace()
+	if err != nil {
+		return err
+	}
+	err = g.StopPondering()
+	if err != nil {
+		return err
+	}
+	if !pos.IsOnBoard(g.BoardSize()) {
+		return board.NewPositionOutOfRangeError(pos.X(), pos.Y(),
+			g.BoardSize())
+	}
+	isLegal, hint, err := rules.IsLegal(g.Settings.Rule, g.Board, g.Step()+1,
+		pos)
+	if err != nil {
+		return err
+	}
+	if !isLegal {
+		return rules.NewIllegalPositionError(pos, hint)
+	}
+	return g.place(pos)
+}
+
+func (g *Game) PlaceByAi() (board.Position, error) {
+	return g.PlaceByAiContext(context.Background())
+}
+
+// Same as PlaceByAi, but the search also stops when ctx is done,
+// and the best position found so far is placed.
+// If no position has been searched, ctx.Err() is returned and
+// the game is not changed.
+func (g *Game) PlaceByAiContext(ctx context.Context) (board.Position, error) {
+	if !g.IsTerminal() && !g.IsAiTurn() {
+		return board.InvalidPosition, ErrNotAiTurn
+	}
+	pos, err := g.SuggestMove(ctx)
+	if err != nil {
+		return board.InvalidPosition, err
+	}
+	return pos, g.place(pos)
+}
+
+// Return the position the AI would place for the player to move, whether
+// it's AI's turn or not, without placing it. See PlaceByAiContext for ctx.
+// Moves in the opening book are returned without search.
+// The tree grown by the search is kept for the following moves.
+func (g *Game) SuggestMove(ctx context.Context) (board.Position, error) {
+	err := g.checkPlace()
+	if err != nil {
+		return board.InvalidPosition, err
+	}
+	err = g.StopPondering()
+	if err != nil {
+		return board.InvalidPosition, err
+	}
+	if pos, ok := g.bookMove(); ok {
+		return pos, nil
+	}
+	if g.Phase != OpeningPhase && g.Phase != ExtraOpeningPhase {
+		// Don't miss a forced win. If ctx is done, leave it to the engine.
+		line, err := solver.Solve(ctx, g.Settings.Rule, g.Board, g.Step()+1,
+			g.aiSolverSettings())
+		if err == nil && len(line) > 0 {
+			return line[0], nil
+		}
+	}
+	if g.Settings.Ai.Engine == AlphaBetaEngine {
+		return g.searchByAlphaBeta(ctx)
+	}
+	return g.searchByMcts(ctx)
+}
+
+// Return the position to place by Monte Carlo tree search.
+// The opponent will choose color after the opening phases,
+// so the most balanced position is returned during them.
+func (g *Game) searchByMcts(ctx context.Context) (board.Position, error) {
+	best, err := g.mctRoot.MonteCarloTreeSearchContext(ctx)
+	if err != nil {
+		return board.InvalidPosition, err
+	}
+	if g.Phase == OpeningPhase || g.Phase == ExtraOpeningPhase {
+		best = g.mctRoot.GetMostBalancedChild()
+	}
+	if best == nil {
+		return board.InvalidPosition, errors.New(
+			"cannot find a position to place stone")
+	}
+	return best.Pos, nil
+}
+
+// Same as searchByMcts, but by alpha-beta search.
+func (g *Game) searchByAlphaBeta(ctx context.Context) (board.Position, error) {
+	search := alphabeta.Search
+	if g.Phase == OpeningPhase || g.Phase == ExtraOpeningPhase {
+		search = alphabeta.SearchBalanced
+	}
+	r, err := search(ctx, g.Settings.Rule, g.Board, g.Step()+1,
+		g.Settings.Ai.AlphaBeta)
+	if err != nil {
+		return board.InvalidPosition, err
+	}
+	return r.Pos, nil
+}
+
+// Place the stone of the next step at pos, which should be legal, and
+// re-root the tree to the node of pos, reusing its subtree if searched.
+func (g *Game) place(pos board.Position) error {
+	g.updateHistoryAndBoard(pos)
+	for node := g.mctRoot.LastChild; node != nil; node = node.PrevSibling {
+		if node.Pos == pos {
+			g.reroot(node)
+			if node.IsTerminal() {
+				g.Outcome = rules.CheckOutcome(g.Settings.Rule, g.Board, pos)
+			}
+			return nil
+		}
+	}
+	// The case: pos is NOT valid but legal, or root is not fully expanded!
+	step := g.mctRoot.Step + 1
+	root, err := g.tree.NewNode(step, pos)
+	if err != nil {
+		return err
+	}
+	g.reroot(root)
+	if root.IsTerminal() {
+		g.Outcome = rules.CheckOutcome(g.Settings.Rule, g.Board, pos)
+	}
+	return nil
+}
+
+// Take back the last n moves.
+// The tree is re-rooted to the previous root if it's still kept,
+// otherwise a new tree is built.
+func (g *Game) Undo(n int) error {
+	if g.IsTearDown() {
+		return ErrTearDown
+	}
+	if n <= 0 || n > len(g.History) {
+		return fmt.Errorf("cannot undo %d moves, number of moves: %d",
+			n, len(g.History))
+	}
+	err := g.StopPondering()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		last := len(g.History) - 1
+		g.Board.Set(g.History[last], 0)
+		g.History = g.History[:last]
+		g.Phase = g.undoStates[last].phase
+		g.AiPiece = g.undoStates[last].aiPiece
+		g.undoStates = g.undoStates[:last]
+		g.Outcome = 0
+		for k := len(g.Choices); k > 0 && g.Choices[k-1].Step > uint(last); k-- {
+			g.Choices = g.Choices[:k-1]
+		}
+
+		var prev *mcts.Node
+		if k := len(g.prevRoots); k > 0 {
+			prev = g.prevRoots[k-1]
+			g.prevRoots[k-1] = nil
+			g.prevRoots = g.prevRoots[:k-1]
+		}
+		if prev != nil && prev.Step+1 == g.mctRoot.Step {
+			g.mctRoot.AttachTo(prev)
+			g.mctRoot = prev
+			g.isRootTerminal = prev.IsTerminal()
+			continue
+		}
+		// The previous root is not kept, build a new tree.
+		for j := range g.prevRoots {
+			g.prevRoots[j] = nil
+		}
+		g.prevRoots = g.prevRoots[:0]
+		pos := board.InvalidPosition
+		if last > 0 {
+			pos = g.History[last-1]
+		}
+		root, err := g.tree.NewNode(uint(last), pos)
+		if err != nil {
+			return err
+		}
+		g.mctRoot = root
+		g.isRootTerminal = root.IsTerminal()
+	}
+	return nil
+}
+
+// Return the number of moves to take back, so that the user's last move is
+// also taken back and it's the user's turn again.
+// Return 0 if the user hasn't placed any stone.
+func (g *Game) NumUndoForUser() int {
+	for i := len(g.History) - 1; i >= 0; i-- {
+		s := g.undoStates[i]
+		if nextPlayerOf(s.phase, uint(i))&s.aiPiece == 0 {
+			return len(g.History) - i
+		}
+	}
+	return 0
+}
+
+// Report whether the color choices swap the colors of the first and
+// the second players, i.e. the first player plays White. The choice is made
+// by White, or by Black after White chooses to place two more stones.
+func (g *Game) IsColorSwapped() bool {
+	chooser := board.White
+	for _, c := range g.Choices {
+		switch c.Choice {
+		case PlaceTwoMore:
+			chooser = board.Black
+		case ChooseBlack, ChooseWhite:
+			return c.Choice.Piece() != chooser
+		}
+	}
+	return false
+}
+
+func (g *Game) Choose(choice ColorChoice) error {
+	err := g.checkChoose()
+	if err != nil {
+		return err
+	}
+	switch choice {
+	case ChooseBlack, ChooseWhite:
+		if choice.Piece() != g.NextPlayer() {
+			// Players swap colors.
+			switch g.AiPiece {
+			case board.Black:
+				g.AiPiece = board.White
+			case board.White:
+				g.AiPiece = board.Black
+			}
+		}
+		g.Phase = NormalPhase
+	case PlaceTwoMore:
+		if g.Phase != ColorChoicePhase ||
+			g.Settings.Rule.Opening() != rules.Swap2Opening {
+			return errors.New("cannot place two more stones now")
+		}
+		g.Phase = ExtraOpeningPhase
+	default:
+		return fmt.Errorf("color choice(%d) is invalid", choice)
+	}
+	g.Choices = append(g.Choices, ChoiceRecord{Step: g.Step(), Choice: choice})
+	return nil
+}
+
+// Evaluate the position by Monte Carlo tree search and make a color choice.
+// Under Swap2, AI chooses to place two more stones if the estimated win rate
+// differs from 50% by no more than Settings.Ai.BalanceThold.
+func (g *Game) ChooseByAi() (ColorChoice, error) {
+	if !g.IsTerminal() && !g.IsAiTurn() {
+		return 0, ErrNotAiTurn
+	}
+	choice, err := g.SuggestChoice()
+	if err != nil {
+		return 0, err
+	}
+	return choice, g.Choose(choice)
+}
+
+// Return the color choice the AI would make for the player to choose,
+// whether it's AI's turn or not, without choosing it.
+// See ChooseByAi for details.
+func (g *Game) SuggestChoice() (ColorChoice, error) {
+	err := g.checkChoose()
+	if err != nil {
+		return 0, err
+	}
+	err = g.StopPondering()
+	if err != nil {
+		return 0, err
+	}
+	best, err := g.mctRoot.MonteCarloTreeSearch()
+	if err != nil {
+		return 0, err
+	}
+	if best == nil || best.NumSim == 0 {
+		return 0, errors.New("cannot evaluate the position")
+	}
+	// White is the next to move after the opening stones,
+	// and best is White's most promising reply.
+	whiteWinRate := best.WinRate()
+	var choice ColorChoice
+	if g.Phase == ColorChoicePhase &&
+		g.Settings.Rule.Opening() == rules.Swap2Opening &&
+		math.Abs(whiteWinRate-.5) <= g.Settings.Ai.BalanceThold {
+		choice = PlaceTwoMore
+	} else if whiteWinRate >= .5 {
+		choice = ChooseWhite
+	} else {
+		choice = ChooseBlack
+	}
+	return choice, nil
+}
+
+// Return InvalidPiece if pos is outside the board.
+func (g *Game) LookupPiece(pos board.Position) board.Piece {
+	if g == nil || !pos.IsOnBoard(g.BoardSize()) {
+		return board.InvalidPiece
+	}
+	return g.Board.Get(pos)
+}
+
+// Check whether a stone can be placed now.
+func (g *Game) checkPlace() error {
+	if g.IsTearDown() {
+		return ErrTearDown
+	}
+	if g.IsTerminal() {
+		return ErrTerminal
+	}
+	if g.Phase.IsColorChoice() {
+		return ErrWaitingForColorChoice
+	}
+	return nil
+}
+
+// Check whether a color can be chosen now.
+func (g *Game) checkChoose() error {
+	if g.IsTearDown() {
+		return ErrTearDown
+	}
+	if g.IsTerminal() {
+		return ErrTerminal
+	}
+	if !g.Phase.IsColorChoice() {
+		return ErrNotColorChoice
+	}
+	return nil
+}
+
+// Make node the root of the tree, and keep the old root for undo.
+func (g *Game) reroot(node *mcts.Node) {
+	if len(g.prevRoots) == maxNumPrevRoots {
+		copy(g.prevRoots, g.prevRoots[1:])
+		g.prevRoots[len(g.prevRoots)-1] = nil
+		g.prevRoots = g.prevRoots[:len(g.prevRoots)-1]
+	}
+	g.prevRoots = append(g.prevRoots, g.mctRoot)
+	g.mctRoot = node
+	g.isRootTerminal = node.IsTerminal()
+	node.TakeOut()
+}
+
+func (g *Game) updateHistoryAndBoard(pos board.Position) {
+	g.undoStates = append(g.undoStates, undoState{
+		phase:   g.Phase,
+		aiPiece: g.AiPiece,
+	})
+	g.History = append(g.History, pos)
+	step := g.mctRoot.Step + 1
+	g.Board.Set(pos, board.PieceOfStep(step))
+	switch {
+	case g.Phase == OpeningPhase && step == rules.NumSwapOpeningStones:
+		g.Phase = ColorChoicePhase
+	case g.Phase == ExtraOpeningPhase &&
+		step == rules.NumSwapOpeningStones+rules.NumSwap2ExtraStones:
+		g.Phase = FinalColorChoicePhase
+	}
+}
+
+// Return the (tentative) color of the player who should act in the phase,
+// when step stones have been placed. See Game.NextPlayer for details.
+func nextPlayerOf(phase GamePhase, step uint) board.Piece {
+	switch phase {
+	case OpeningPhase, FinalColorChoicePhase:
+		return board.Black
+	case ColorChoicePhase, ExtraOpeningPhase:
+		return board.White
+	default:
+		return board.PieceOfStep(step + 1)
+	}
+}
@@ -0,0 +1,683 @@
+package game
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donyori/goctpf"
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/book"
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+	"github.com/donyori/ucashw_gt_gomoku/solver"
+)
+
+func TestSwap2Opening(t *testing.T) {
+	settings := NewSettings()
+	settings.Rule = rules.GomokuSwap2
+	settings.Ai.AiPiece = board.White
+	settings.Ai.MctsTimeLimit = time.Millisecond * 200
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	if game.Phase != OpeningPhase {
+		t.Fatalf("phase: %v, want %v", game.Phase, OpeningPhase)
+	}
+	for _, s := range []string{"h8", "h9", "j10"} {
+		if game.IsAiTurn() {
+			t.Fatal("AI's turn during the opening of the first player")
+		}
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = game.PlaceByUser(pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if game.Phase != ColorChoicePhase {
+		t.Fatalf("phase: %v, want %v", game.Phase, ColorChoicePhase)
+	}
+	if !game.IsAiTurn() {
+		t.Fatal("not AI's turn to choose color")
+	}
+	// Make AI place two more stones.
+	err = game.Choose(PlaceTwoMore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if game.Phase != ExtraOpeningPhase || !game.IsAiTurn() {
+			t.Fatalf("phase: %v, is AI's turn: %t", game.Phase, game.IsAiTurn())
+		}
+		pos, err := game.PlaceByAi()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log("AI places", pos)
+	}
+	if game.Phase != FinalColorChoicePhase || game.IsAiTurn() {
+		t.Fatalf("phase: %v, is AI's turn: %t", game.Phase, game.IsAiTurn())
+	}
+	// The first player takes White, so AI becomes Black.
+	err = game.Choose(ChooseWhite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Phase != NormalPhase || game.AiPiece != board.Black {
+		t.Fatalf("phase: %v, AI piece: %v", game.Phase, game.AiPiece)
+	}
+	if game.NextTurn() != board.White || game.IsAiTurn() {
+		t.Fatalf("next turn: %v, is AI's turn: %t", game.NextTurn(),
+			game.IsAiTurn())
+	}
+}
+
+func TestSwapChooseByAi(t *testing.T) {
+	settings := NewSettings()
+	settings.Rule = rules.GomokuSwap
+	settings.Ai.AiPiece = board.Both
+	settings.Ai.MctsTimeLimit = time.Millisecond * 200
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	for i := uint(0); i < rules.NumSwapOpeningStones; i++ {
+		pos, err := game.PlaceByAi()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log("AI places", pos)
+	}
+	if game.Phase != ColorChoicePhase {
+		t.Fatalf("phase: %v, want %v", game.Phase, ColorChoicePhase)
+	}
+	if err = game.Choose(PlaceTwoMore); err == nil {
+		t.Error("PlaceTwoMore is accepted under Swap")
+	}
+	choice, err := game.ChooseByAi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("AI chooses", choice)
+	if choice != ChooseBlack && choice != ChooseWhite {
+		t.Errorf("choice: %v", choice)
+	}
+	if game.Phase != NormalPhase || game.AiPiece != board.Both {
+		t.Fatalf("phase: %v, AI piece: %v", game.Phase, game.AiPiece)
+	}
+}
+
+func TestBoardSize(t *testing.T) {
+	settings := NewSettings()
+	settings.BoardSize = board.MinBoardSize - 1
+	if _, err := NewGame(settings); err == nil {
+		t.Error("no error for too small board size")
+	}
+	settings.BoardSize = 20
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	for _, s := range []string{"k11", "t20"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = game.PlaceByUser(pos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pos, err := board.ParsePosition("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	isLegal, hint, err := rules.IsLegal(settings.Rule, game.Board,
+		game.Step()+1, pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isLegal {
+		t.Errorf("%v is legal on 20×20 board", pos)
+	}
+	t.Log(hint)
+	for i := 0; i < 20; i++ {
+		if _, err = game.mctRoot.Simulate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Logf("root - NumWin: %d, NumSim: %d", game.mctRoot.NumWin,
+		game.mctRoot.NumSim)
+}
+
+func TestUndo(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.MctsTimeLimit = time.Millisecond * 200
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	root := game.mctRoot
+	if n := game.NumUndoForUser(); n != 0 {
+		t.Errorf("NumUndoForUser() = %d before any move", n)
+	}
+	if err = game.Undo(1); err == nil {
+		t.Error("no error for undo before any move")
+	}
+	pos, err := board.ParsePosition("h8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = game.PlaceByUser(pos); err != nil {
+		t.Fatal(err)
+	}
+	aiPos, err := game.PlaceByAi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aiNode := game.mctRoot
+	n := game.NumUndoForUser()
+	if n != 2 {
+		t.Fatalf("NumUndoForUser() = %d, want 2", n)
+	}
+	if err = game.Undo(n); err != nil {
+		t.Fatal(err)
+	}
+	if game.Step() != 0 || game.Board.NumStone() != 0 ||
+		game.Board.Get(aiPos) != 0 || game.Outcome != 0 {
+		t.Fatalf("step: %d, number of stones: %d, outcome: %v", game.Step(),
+			game.Board.NumStone(), game.Outcome)
+	}
+	if game.mctRoot != root {
+		t.Error("root is not reused")
+	}
+	var isFound bool
+	for node := root.LastChild; node != nil && !isFound; node = node.PrevSibling {
+		for child := node.LastChild; child != nil; child = child.PrevSibling {
+			if child == aiNode {
+				isFound = true
+				break
+			}
+		}
+	}
+	if !isFound {
+		t.Error("subtree is not reused")
+	}
+	if err = game.PlaceByUser(pos); err != nil {
+		t.Fatal(err)
+	}
+	if game.Step() != 1 || game.NextTurn() != board.White {
+		t.Errorf("step: %d, next turn: %v", game.Step(), game.NextTurn())
+	}
+}
+
+func TestUndoSwap(t *testing.T) {
+	settings := NewSettings()
+	settings.Rule = rules.GomokuSwap
+	settings.Ai.AiPiece = board.White
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	for _, s := range []string{"h8", "h9", "j10"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = game.PlaceByUser(pos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// AI(the second player) takes Black, so the user plays the 4th stone.
+	if err = game.Choose(ChooseBlack); err != nil {
+		t.Fatal(err)
+	}
+	pos, err := board.ParsePosition("g7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = game.PlaceByUser(pos); err != nil {
+		t.Fatal(err)
+	}
+	if n := game.NumUndoForUser(); n != 1 {
+		t.Fatalf("NumUndoForUser() = %d, want 1", n)
+	}
+	if err = game.Undo(2); err != nil {
+		t.Fatal(err)
+	}
+	if game.Phase != OpeningPhase || game.AiPiece != board.White ||
+		game.IsAiTurn() {
+		t.Errorf("phase: %v, AI piece: %v, is AI's turn: %t", game.Phase,
+			game.AiPiece, game.IsAiTurn())
+	}
+}
+
+func TestPondering(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.White
+	settings.Ai.MctsTimeLimit = time.Millisecond * 100
+	settings.Ai.PonderTimeLimit = time.Second * 10
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	h8, err := board.ParsePosition("h8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = game.PlaceByUser(h8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = game.PlaceByAi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	numSim := game.mctRoot.NumSim
+	err = game.StartPondering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !game.IsPondering() {
+		t.Fatal("not pondering")
+	}
+	time.Sleep(time.Millisecond * 200)
+	if game.IsTerminal() || game.IsAiTurn() {
+		t.Fatalf("is terminal: %t, is AI's turn: %t", game.IsTerminal(),
+			game.IsAiTurn())
+	}
+	err = game.StopPondering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.mctRoot.NumSim <= numSim {
+		t.Fatalf("NumSim: %d, before pondering: %d", game.mctRoot.NumSim,
+			numSim)
+	}
+	best := game.mctRoot.GetBestNumSimChild()
+	if best == nil {
+		t.Fatal("no child after pondering")
+	}
+	err = game.StartPondering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// PlaceByUser stops pondering and reuses the subtree.
+	err = game.PlaceByUser(best.Pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.IsPondering() {
+		t.Fatal("still pondering after PlaceByUser")
+	}
+	if game.mctRoot != best || best.NumSim == 0 {
+		t.Fatalf("root: %p(NumSim: %d), want %p", game.mctRoot,
+			game.mctRoot.NumSim, best)
+	}
+}
+
+func TestPonderingDisabled(t *testing.T) {
+	seeded := NewSettings()
+	seeded.Ai.Seed = 7
+	alphaBeta := NewSettings()
+	alphaBeta.Ai.Engine = AlphaBetaEngine
+	for _, settings := range []*Settings{seeded, alphaBeta} {
+		game, err := NewGame(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = game.StartPondering()
+		if err != nil {
+			game.TearDown()
+			t.Fatal(err)
+		}
+		if game.IsPondering() {
+			t.Errorf("pondering with seed %d, engine %q", settings.Ai.Seed,
+				settings.Ai.Engine)
+		}
+		game.TearDown()
+	}
+
+	// Black to move wins at L8, so the root is proven after a search.
+	settings := NewSettings()
+	settings.Ai.AiPiece = 0
+	settings.Ai.PonderTimeLimit = time.Second * 10
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	for _, s := range []string{"h8", "a1", "i8", "a3", "j8", "a5", "k8", "a7"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = game.PlaceByUser(pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = game.Analyze(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof := game.mctRoot.Proof(); proof == mcts.NotProven {
+		t.Fatal("root is not proven")
+	}
+	err = game.StartPondering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.IsPondering() {
+		t.Error("pondering on a proven position")
+	}
+}
+
+func TestPlaceByAiContext(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.Black
+	settings.Ai.MctsTimeLimit = time.Minute
+	// Search the first move rather than taking it from the book.
+	settings.Ai.Book = &book.Settings{}
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = game.PlaceByAiContext(ctx)
+	if err != context.Canceled {
+		t.Fatalf("error: %v, want %v", err, context.Canceled)
+	}
+	if game.Step() != 0 || !game.IsAiTurn() {
+		t.Fatalf("step: %d, is AI's turn: %t", game.Step(), game.IsAiTurn())
+	}
+	ctx, cancel = context.WithTimeout(context.Background(),
+		time.Millisecond*100)
+	defer cancel()
+	pos, err := game.PlaceByAiContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Step() != 1 || game.LookupPiece(pos) != board.Black {
+		t.Fatalf("step: %d, piece at %v: %v", game.Step(), pos,
+			game.LookupPiece(pos))
+	}
+}
+
+func TestSeedReproducible(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.Both
+	settings.Ai.Seed = 7
+	settings.Ai.MctsNumSim = 200
+	settings.Worker = &goctpf.WorkerSettings{Number: 1}
+	var histories [2][]board.Position
+	for i := range histories {
+		game, err := NewGame(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 6 && !game.IsTerminal(); j++ {
+			_, err = game.PlaceByAi()
+			if err != nil {
+				game.TearDown()
+				t.Fatal(err)
+			}
+		}
+		histories[i] = game.History
+		game.TearDown()
+	}
+	t.Log("History:", histories[0])
+	if !reflect.DeepEqual(histories[0], histories[1]) {
+		t.Errorf("histories differ: %v and %v", histories[0], histories[1])
+	}
+}
+
+func TestSeedReproducibleSolver(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.Both
+	settings.Ai.Seed = 7
+	settings.Ai.MctsNumSim = 20
+	// Search all moves, with the solver limited by nodes rather than time.
+	settings.Ai.Book = &book.Settings{}
+	settings.Ai.Solver = &solver.Settings{VcfDepth: 10, VctDepth: 3,
+		TimeLimit: time.Nanosecond, MaxNode: 2000}
+	settings.Worker = &goctpf.WorkerSettings{Number: 1}
+	var histories [2][]board.Position
+	for i := range histories {
+		game, err := NewGame(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 20 && !game.IsTerminal(); j++ {
+			_, err = game.PlaceByAi()
+			if err != nil {
+				game.TearDown()
+				t.Fatal(err)
+			}
+		}
+		histories[i] = game.History
+		game.TearDown()
+	}
+	t.Log("History:", histories[0])
+	if !reflect.DeepEqual(histories[0], histories[1]) {
+		t.Errorf("histories differ: %v and %v", histories[0], histories[1])
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.PonderTimeLimit = 0
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	for _, s := range []string{"H8", "H9", "I8"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = game.PlaceByUser(pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err := game.Analyze(time.Millisecond * 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.NumSim == 0 || len(a.Moves) == 0 {
+		t.Fatalf("NumSim: %d, number of moves: %d", a.NumSim, len(a.Moves))
+	}
+	if game.Step() != 3 {
+		t.Errorf("step: %d, want 3", game.Step())
+	}
+	for i, m := range a.Moves {
+		if i > 0 && m.NumSim > a.Moves[i-1].NumSim {
+			t.Errorf("move %d (%v) has more simulations than move %d", i,
+				m.Pos, i-1)
+		}
+		if len(m.PrincipalVariation) == 0 || m.PrincipalVariation[0] != m.Pos {
+			t.Errorf("move %v, principal variation: %v", m.Pos,
+				m.PrincipalVariation)
+		}
+		if m.WinRate < 0. || m.WinRate > 1. {
+			t.Errorf("move %v, win rate: %f", m.Pos, m.WinRate)
+		}
+	}
+	t.Log("Best move:", a.Moves[0].Pos, "PV:", a.Moves[0].PrincipalVariation)
+}
+
+// The hint command of the console analyzes the user's turn, which must not
+// change the game.
+func TestAnalyzeUserTurn(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.White
+	settings.Ai.PonderTimeLimit = 0
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	h8, err := board.ParsePosition("h8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = game.PlaceByUser(h8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = game.PlaceByAi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := game.mctRoot
+	history := append([]board.Position(nil), game.History...)
+	b := *game.Board
+	a, err := game.Analyze(time.Millisecond * 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Moves) == 0 {
+		t.Fatal("no move to suggest")
+	}
+	isLegal, _, err := rules.IsLegal(game.Settings.Rule, game.Board,
+		game.Step()+1, a.Moves[0].Pos)
+	if err != nil || !isLegal {
+		t.Errorf("suggested move %v: is legal: %t, error: %v",
+			a.Moves[0].Pos, isLegal, err)
+	}
+	if game.AiPiece != board.White || game.IsAiTurn() || game.Phase !=
+		NormalPhase || game.mctRoot != root || root.Step != 2 {
+		t.Errorf("AI piece: %v, is AI's turn: %t, phase: %v, root step: %d",
+			game.AiPiece, game.IsAiTurn(), game.Phase, game.mctRoot.Step)
+	}
+	if !reflect.DeepEqual(game.History, history) || *game.Board != b {
+		t.Errorf("history: %v, want %v", game.History, history)
+	}
+}
+
+func TestAlphaBetaEngine(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.Both
+	settings.Ai.Engine = AlphaBetaEngine
+	settings.Ai.AlphaBeta.TimeLimit = time.Millisecond * 200
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	for i := 0; i < 6 && !game.IsTerminal(); i++ {
+		pos, err := game.PlaceByAi()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.LookupPiece(pos) != board.PieceOfStep(game.Step()) {
+			t.Fatalf("step: %d, piece at %v: %v", game.Step(), pos,
+				game.LookupPiece(pos))
+		}
+	}
+	t.Log("History:", game.History)
+	// The tree should follow the moves, for undo and analyses.
+	err = game.Undo(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = game.Analyze(time.Millisecond * 50); err != nil {
+		t.Fatal(err)
+	}
+
+	settings.Ai.Engine = "unknown"
+	if _, err = NewGame(settings); err == nil {
+		t.Error("no error for an unknown engine")
+	}
+}
+
+func TestPlaceByAiForcedWin(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.Both
+	// Too few simulations to find the win without the solver.
+	settings.Ai.MctsNumSim = 1
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	// Black's K8 makes a double four.
+	for _, s := range []string{"H8", "G8", "I8", "K12", "J8", "A1", "K9",
+		"O1", "K10", "A15", "K11", "O15"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = game.PlaceByUser(pos)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	line, err := game.Solve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(line) == 0 || line[0].String() != "K8" {
+		t.Fatalf("winning line: %v, want K8 first", line)
+	}
+	for !game.IsTerminal() {
+		_, err = game.PlaceByAi()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Log("History:", game.History[12:])
+	if game.History[12].String() != "K8" || game.Outcome != board.Black {
+		t.Errorf("moves: %v, outcome: %v", game.History[12:], game.Outcome)
+	}
+}
+
+func TestPlaceByAiBook(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.AiPiece = board.Black
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	// Book moves are placed without search, even if ctx is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pos, err := game.PlaceByAiContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.String() != "H8" {
+		t.Fatalf("first move: %v, want H8", pos)
+	}
+	// G7 is I9 of the book, reflected.
+	pos, err = board.ParsePosition("G7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = game.PlaceByUser(pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos, err = game.PlaceByAiContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := pos.String(); s != "G8" && s != "H7" {
+		t.Errorf("third move: %v, want G8 or H7", pos)
+	}
+}
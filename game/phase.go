@@ -0,0 +1,96 @@
+package game
+
+import (
+	"strings"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// Phases of a game. Only the rules with an opening protocol (e.g. Swap and
+// Swap2) go through phases other than NormalPhase.
+type GamePhase int8
+
+const (
+	NormalPhase           GamePhase = iota // Players place stones by turns.
+	OpeningPhase                           // The first player places the opening stones.
+	ColorChoicePhase                       // The second player chooses color.
+	ExtraOpeningPhase                      // The second player places two more stones (Swap2).
+	FinalColorChoicePhase                  // The first player chooses color (Swap2).
+)
+
+type ColorChoice int8
+
+const (
+	ChooseBlack ColorChoice = iota + 1
+	ChooseWhite
+	PlaceTwoMore // Only available in ColorChoicePhase under Swap2.
+)
+
+func (gp GamePhase) IsColorChoice() bool {
+	return gp == ColorChoicePhase || gp == FinalColorChoicePhase
+}
+
+func (gp GamePhase) String() string {
+	switch gp {
+	case NormalPhase:
+		return "Normal"
+	case OpeningPhase:
+		return "Opening"
+	case ColorChoicePhase:
+		return "ColorChoice"
+	case ExtraOpeningPhase:
+		return "ExtraOpening"
+	case FinalColorChoicePhase:
+		return "FinalColorChoice"
+	default:
+		return "Unknown"
+	}
+}
+
+func ParseColorChoice(s string) ColorChoice {
+	s = strings.ReplaceAll(strings.ToLower(s), " ", "_")
+	switch s {
+	case "b", "black":
+		return ChooseBlack
+	case "w", "white":
+		return ChooseWhite
+	case "p", "place", "place_two", "place_two_more", "placetwomore":
+		return PlaceTwoMore
+	default:
+		return 0
+	}
+}
+
+// Return the chosen color, or 0 for PlaceTwoMore and invalid choices.
+func (cc ColorChoice) Piece() board.Piece {
+	switch cc {
+	case ChooseBlack:
+		return board.Black
+	case ChooseWhite:
+		return board.White
+	default:
+		return 0
+	}
+}
+
+func (cc ColorChoice) String() string {
+	switch cc {
+	case ChooseBlack:
+		return "Black"
+	case ChooseWhite:
+		return "White"
+	case PlaceTwoMore:
+		return "PlaceTwoMore"
+	default:
+		return "Invalid"
+	}
+}
+
+func (cc ColorChoice) MarshalText() ([]byte, error) {
+	return []byte(cc.String()), nil
+}
+
+func (cc *ColorChoice) UnmarshalText(text []byte) error {
+	*cc = ParseColorChoice(string(text))
+	return nil
+}
@@ -0,0 +1,219 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/donyori/goctpf"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+var ErrScriptEnded error = errors.New("script is ended")
+
+// Player makes the moves of one side of a game, e.g. a user, an engine or
+// a remote agent, so that Runner can drive any two players.
+type Player interface {
+	// Return the position to place the stone of the next step of g.
+	// g should not be changed, except that it's replaced by Runner.Game.
+	NextMove(ctx context.Context, g *Game) (board.Position, error)
+	// Notify the player of the move just placed by either player.
+	Notify(move board.Position)
+}
+
+// A player that can also make color choices under swap openings.
+type ColorChooser interface {
+	Player
+	ChooseColor(ctx context.Context, g *Game) (ColorChoice, error)
+}
+
+// AiPlayer plays by the search of the AI.
+type AiPlayer struct {
+	// Settings of the AI. If nil, the player searches on the game it plays,
+	// sharing its tree and settings.
+	// Otherwise, it searches on its own game following the game it plays,
+	// so that AIs of different settings can play against each other.
+	// The rule and board size are always those of the game it plays.
+	Ai     *AiSettings
+	Worker *goctpf.WorkerSettings
+
+	game *Game
+}
+
+func (p *AiPlayer) NextMove(ctx context.Context, g *Game) (
+	board.Position, error) {
+	own, err := p.follow(g)
+	if err != nil {
+		return board.InvalidPosition, err
+	}
+	return own.SuggestMove(ctx)
+}
+
+// The player's own game follows the game it plays in NextMove and
+// ChooseColor, so nothing to do here.
+func (p *AiPlayer) Notify(move board.Position) {}
+
+func (p *AiPlayer) ChooseColor(ctx context.Context, g *Game) (
+	ColorChoice, error) {
+	own, err := p.follow(g)
+	if err != nil {
+		return 0, err
+	}
+	return own.SuggestChoice()
+}
+
+// Tear down the player's own game, if any.
+func (p *AiPlayer) Close() {
+	p.game.TearDown()
+	p.game = nil
+}
+
+// Return the game to search, which is g or the player's own game
+// updated to g.
+func (p *AiPlayer) follow(g *Game) (*Game, error) {
+	if g.IsTearDown() {
+		return nil, ErrTearDown
+	}
+	if p.Ai == nil {
+		return g, nil
+	}
+	if p.game != nil && p.game.Settings.Rule == g.Settings.Rule &&
+		p.game.BoardSize() == g.BoardSize() {
+		ok, err := p.game.catchUp(g)
+		if ok || err != nil {
+			return p.game, err
+		}
+	}
+	// Replay g on a new game, e.g. after an undo.
+	p.Close()
+	gr, err := g.Record()
+	if err != nil {
+		return nil, err
+	}
+	p.game, err = gr.Replay(&Settings{
+		Rule:      g.Settings.Rule,
+		BoardSize: g.BoardSize(),
+		Ai:        p.Ai,
+		Worker:    p.Worker,
+	})
+	return p.game, err
+}
+
+// Apply the moves and choices of src after those of g.
+// Return false if the moves or choices of g are not a prefix of src's.
+func (g *Game) catchUp(src *Game) (bool, error) {
+	n := len(g.History)
+	if n > len(src.History) || len(g.Choices) > len(src.Choices) {
+		return false, nil
+	}
+	for i := 0; i < n; i++ {
+		if g.History[i] != src.History[i] {
+			return false, nil
+		}
+	}
+	for i := range g.Choices {
+		if g.Choices[i] != src.Choices[i] {
+			return false, nil
+		}
+	}
+	c := len(g.Choices)
+	for i := n; ; i++ {
+		for ; c < len(src.Choices) && src.Choices[c].Step == uint(i); c++ {
+			err := g.Choose(src.Choices[c].Choice)
+			if err != nil {
+				return false, err
+			}
+		}
+		if i == len(src.History) {
+			return true, nil
+		}
+		err := g.PlaceByUser(src.History[i])
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// RandomPlayer places stones at random valid positions, e.g. as a baseline.
+type RandomPlayer struct {
+	rng *rand.Rand
+}
+
+// Maximum distance from the stones to the positions of RandomPlayer.
+const randomPlayerDistThold int = 2
+
+// seed 0 for a seed from the clock.
+func NewRandomPlayer(seed int64) *RandomPlayer {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &RandomPlayer{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (p *RandomPlayer) NextMove(ctx context.Context, g *Game) (
+	board.Position, error) {
+	vps := rules.GetValidPositions(g.Settings.Rule, g.Board, g.Step()+1,
+		randomPlayerDistThold)
+	if len(vps) == 0 {
+		return board.InvalidPosition, errors.New(
+			"cannot find a position to place stone")
+	}
+	return vps[p.rng.Intn(len(vps))], nil
+}
+
+func (p *RandomPlayer) Notify(move board.Position) {}
+
+func (p *RandomPlayer) ChooseColor(ctx context.Context, g *Game) (
+	ColorChoice, error) {
+	if p.rng.Intn(2) == 0 {
+		return ChooseBlack, nil
+	}
+	return ChooseWhite, nil
+}
+
+// ScriptedPlayer replays the moves and choices of a record, whichever side
+// it plays. If the game deviates from the record, its moves may be illegal.
+type ScriptedPlayer struct {
+	Moves   []board.Position
+	Choices []ChoiceRecord
+}
+
+func NewScriptedPlayer(gr *GameRecord) (*ScriptedPlayer, error) {
+	if gr == nil {
+		return nil, errors.New("game record is nil")
+	}
+	p := &ScriptedPlayer{
+		Moves:   make([]board.Position, len(gr.Moves)),
+		Choices: gr.Choices,
+	}
+	for i, s := range gr.Moves {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			return nil, err
+		}
+		p.Moves[i] = pos
+	}
+	return p, nil
+}
+
+func (p *ScriptedPlayer) NextMove(ctx context.Context, g *Game) (
+	board.Position, error) {
+	if g.Step() >= uint(len(p.Moves)) {
+		return board.InvalidPosition, ErrScriptEnded
+	}
+	return p.Moves[g.Step()], nil
+}
+
+func (p *ScriptedPlayer) Notify(move board.Position) {}
+
+func (p *ScriptedPlayer) ChooseColor(ctx context.Context, g *Game) (
+	ColorChoice, error) {
+	c := len(g.Choices)
+	if c >= len(p.Choices) || p.Choices[c].Step != g.Step() {
+		return 0, ErrScriptEnded
+	}
+	return p.Choices[c].Choice, nil
+}
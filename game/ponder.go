@@ -0,0 +1,72 @@
+package game
+
+import (
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+)
+
+// Start searching the current position in the background, e.g. while
+// waiting for the user's move, so that the subtree of that move is already
+// grown when PlaceByUser reuses it.
+// It stops after Settings.Ai.PonderTimeLimit, or once the position is
+// proven. It does nothing if the limit is not positive, Settings.Ai.Seed is
+// set, Settings.Ai.Engine is AlphaBetaEngine, the game is over,
+// the position is already proven, or it's already pondering.
+// Methods changing the game (PlaceByUser, PlaceByAi, Undo, ChooseByAi and
+// TearDown) stop pondering first. Other methods are safe to call meanwhile,
+// but the search reads Board, so it must not be modified, even temporarily
+// like rules.IsLegal does under Renju. Check a copy of it instead.
+func (g *Game) StartPondering() error {
+	if g.IsTearDown() {
+		return ErrTearDown
+	}
+	ai := g.Settings.Ai
+	if g.ponderStopChan != nil || g.IsTerminal() || ai.PonderTimeLimit <= 0 ||
+		ai.Seed != 0 || ai.Engine == AlphaBetaEngine ||
+		g.mctRoot.Proof() != mcts.NotProven {
+		return nil
+	}
+	stopChan := make(chan struct{})
+	doneChan := make(chan error, 1)
+	g.ponderStopChan = stopChan
+	g.ponderDoneChan = doneChan
+	go ponder(g.mctRoot, ai.PonderTimeLimit, stopChan, doneChan)
+	return nil
+}
+
+func (g *Game) IsPondering() bool {
+	return g != nil && g.ponderStopChan != nil
+}
+
+// Stop pondering and wait for the background search to exit.
+// Return the error occurred in the search, if any.
+func (g *Game) StopPondering() error {
+	if !g.IsPondering() {
+		return nil
+	}
+	close(g.ponderStopChan)
+	err := <-g.ponderDoneChan
+	g.ponderStopChan = nil
+	g.ponderDoneChan = nil
+	return err
+}
+
+func ponder(root *mcts.Node, timeLimit time.Duration,
+	stopChan <-chan struct{}, doneChan chan<- error) {
+	deadline := time.Now().Add(timeLimit)
+	for time.Now().Before(deadline) && root.Proof() == mcts.NotProven {
+		select {
+		case <-stopChan:
+			doneChan <- nil
+			return
+		default:
+		}
+		_, err := root.Simulate()
+		if err != nil {
+			doneChan <- err
+			return
+		}
+	}
+	doneChan <- nil
+}
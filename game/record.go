@@ -0,0 +1,193 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// Version of the game record format.
+const GameRecordVersion int = 1
+
+// Record of a game, to save it and resume it later.
+type GameRecord struct {
+	Version   int            `json:"version"`
+	Rule      rules.Rule     `json:"rule"`
+	BoardSize int            `json:"board_size"`
+	Settings  *Settings      `json:"settings,omitempty"`
+	Moves     []string       `json:"moves"`
+	Choices   []ChoiceRecord `json:"choices,omitempty"`
+	Players   PlayersRecord  `json:"players"`
+	// "Black", "White", "Draw", or empty if the game is not over.
+	Result    string    `json:"result,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	SaveTime  time.Time `json:"save_time"`
+}
+
+// A color choice in the opening, made after Step stones have been placed.
+type ChoiceRecord struct {
+	Step   uint        `json:"step"`
+	Choice ColorChoice `json:"choice"`
+}
+
+// Player names of each color, "AI" or "Human".
+type PlayersRecord struct {
+	Black string `json:"black"`
+	White string `json:"white"`
+}
+
+func (g *Game) Record() (*GameRecord, error) {
+	if g.IsTearDown() {
+		return nil, ErrTearDown
+	}
+	gr := &GameRecord{
+		Version:   GameRecordVersion,
+		Rule:      g.Settings.Rule,
+		BoardSize: g.BoardSize(),
+		Settings:  g.Settings,
+		Moves:     make([]string, len(g.History)),
+		Choices:   append([]ChoiceRecord(nil), g.Choices...),
+		Players:   PlayersRecord{Black: "Human", White: "Human"},
+		StartTime: g.StartTime,
+		SaveTime:  time.Now(),
+	}
+	for i, pos := range g.History {
+		gr.Moves[i] = pos.String()
+	}
+	if g.AiPiece&board.Black != 0 {
+		gr.Players.Black = "AI"
+	}
+	if g.AiPiece&board.White != 0 {
+		gr.Players.White = "AI"
+	}
+	if g.IsTerminal() {
+		switch g.Outcome {
+		case board.Black, board.White:
+			gr.Result = g.Outcome.String()
+		default:
+			gr.Result = "Draw"
+		}
+	}
+	return gr, nil
+}
+
+// Write the record of the game to w in JSON.
+func (g *Game) SaveRecord(w io.Writer) error {
+	if w == nil {
+		return errors.New("w is nil")
+	}
+	gr, err := g.Record()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(gr, "", "    ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
+
+// Read a game record in JSON from r, and replay it to a new game.
+// The rule, board size and initial AI piece are taken from the record,
+// and other settings from settings. If settings is nil, the settings in
+// the record are used, or default settings if the record has none.
+func LoadGame(r io.Reader, settings *Settings) (*Game, error) {
+	if r == nil {
+		return nil, errors.New("r is nil")
+	}
+	gr := new(GameRecord)
+	err := json.NewDecoder(r).Decode(gr)
+	if err != nil {
+		return nil, err
+	}
+	return gr.Replay(settings)
+}
+
+// Create a new game and replay the record on it.
+// See LoadGame for the settings used.
+func (gr *GameRecord) Replay(settings *Settings) (*Game, error) {
+	if gr.Version > GameRecordVersion {
+		return nil, fmt.Errorf("game record version %d is not supported",
+			gr.Version)
+	}
+	if settings == nil {
+		settings = gr.Settings
+		if settings == nil {
+			settings = NewSettings()
+		}
+	}
+	s := *settings
+	s.Rule = gr.Rule
+	s.BoardSize = gr.BoardSize
+	if s.Ai == nil {
+		s.Ai = NewSettings().Ai
+	}
+	if gr.Settings != nil && gr.Settings.Ai != nil {
+		ai := *s.Ai
+		ai.AiPiece = gr.Settings.Ai.AiPiece
+		s.Ai = &ai
+	}
+	if s.Worker == nil {
+		s.Worker = NewSettings().Worker
+	}
+	g, err := NewGame(&s)
+	if err != nil {
+		return nil, err
+	}
+	if !gr.StartTime.IsZero() {
+		g.StartTime = gr.StartTime
+	}
+	err = g.replay(gr)
+	if err != nil {
+		g.TearDown()
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *Game) replay(gr *GameRecord) error {
+	var c int
+	for i := 0; i <= len(gr.Moves); i++ {
+		for ; c < len(gr.Choices) && gr.Choices[c].Step == uint(i); c++ {
+			if !g.Phase.IsColorChoice() {
+				return fmt.Errorf("choice %d(%v) is not expected after %d moves",
+					c+1, gr.Choices[c].Choice, i)
+			}
+			err := g.Choose(gr.Choices[c].Choice)
+			if err != nil {
+				return fmt.Errorf("choice %d: %v", c+1, err)
+			}
+		}
+		if i == len(gr.Moves) {
+			break
+		}
+		pos, err := board.ParsePosition(gr.Moves[i])
+		if err != nil {
+			return fmt.Errorf("move %d: %v", i+1, err)
+		}
+		if g.IsTerminal() {
+			return fmt.Errorf("move %d(%v) is after the end of the game",
+				i+1, pos)
+		}
+		if g.Phase.IsColorChoice() {
+			return fmt.Errorf("move %d(%v) is before the color choice",
+				i+1, pos)
+		}
+		err = g.PlaceByUser(pos)
+		if err != nil {
+			return fmt.Errorf("move %d: %v", i+1, err)
+		}
+	}
+	if c < len(gr.Choices) {
+		return fmt.Errorf("choice %d(%v) is not expected after %d moves",
+			c+1, gr.Choices[c].Choice, gr.Choices[c].Step)
+	}
+	return nil
+}
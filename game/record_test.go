@@ -0,0 +1,144 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestSaveAndLoadGame(t *testing.T) {
+	settings := NewSettings()
+	settings.Rule = rules.GomokuSwap
+	settings.BoardSize = 13
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	for _, s := range []string{"g7", "g8", "h9"} {
+		pos, err := board.ParsePosition(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = game.PlaceByUser(pos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = game.Choose(ChooseBlack); err != nil {
+		t.Fatal(err)
+	}
+	pos, err := board.ParsePosition("f6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = game.PlaceByUser(pos); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = game.SaveRecord(&buf); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("\n" + buf.String())
+
+	loaded, err := LoadGame(&buf, NewSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.TearDown()
+	if loaded.Settings.Rule != rules.GomokuSwap || loaded.BoardSize() != 13 {
+		t.Errorf("rule: %v, board size: %d", loaded.Settings.Rule,
+			loaded.BoardSize())
+	}
+	if len(loaded.History) != len(game.History) {
+		t.Fatalf("history: %v, want %v", loaded.History, game.History)
+	}
+	for i := range game.History {
+		if loaded.History[i] != game.History[i] {
+			t.Fatalf("history: %v, want %v", loaded.History, game.History)
+		}
+	}
+	if loaded.Phase != game.Phase || loaded.AiPiece != game.AiPiece ||
+		loaded.Outcome != game.Outcome || loaded.mctRoot.Step != 4 {
+		t.Errorf("phase: %v, AI piece: %v, outcome: %v, root step: %d",
+			loaded.Phase, loaded.AiPiece, loaded.Outcome, loaded.mctRoot.Step)
+	}
+	if !loaded.StartTime.Equal(game.StartTime) {
+		t.Errorf("start time: %v, want %v", loaded.StartTime, game.StartTime)
+	}
+}
+
+func TestLoadGameFinished(t *testing.T) {
+	record := `{"rule": "StandardGomoku", "board_size": 15,
+		"moves": ["h8", "a1", "h9", "a2", "h10", "a3", "h11", "a4", "h12"]}`
+	game, err := LoadGame(strings.NewReader(record), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	if !game.IsTerminal() || game.Outcome != board.Black {
+		t.Errorf("is terminal: %t, outcome: %v", game.IsTerminal(), game.Outcome)
+	}
+	gr, err := game.Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gr.Result != "Black" {
+		t.Errorf("result: %q", gr.Result)
+	}
+}
+
+func TestGameRecordPlayers(t *testing.T) {
+	cases := []struct {
+		aiPiece board.Piece
+		want    PlayersRecord
+	}{
+		{0, PlayersRecord{Black: "Human", White: "Human"}},
+		{board.Black, PlayersRecord{Black: "AI", White: "Human"}},
+		{board.White, PlayersRecord{Black: "Human", White: "AI"}},
+		{board.Both, PlayersRecord{Black: "AI", White: "AI"}},
+	}
+	for _, c := range cases {
+		settings := NewSettings()
+		settings.Ai.AiPiece = c.aiPiece
+		settings.Ai.PonderTimeLimit = 0
+		game, err := NewGame(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gr, err := game.Record()
+		game.TearDown()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gr.Players != c.want {
+			t.Errorf("AI piece %v: players: %+v, want %+v", c.aiPiece,
+				gr.Players, c.want)
+		}
+	}
+}
+
+func TestLoadGameInvalid(t *testing.T) {
+	records := []string{
+		`{"rule": "StandardGomoku", "board_size": 15, "moves": ["h8", "h8"]}`,
+		`{"rule": "StandardGomoku", "board_size": 9, "moves": ["h8", "k10"]}`,
+		`{"rule": "StandardGomoku", "board_size": 15,
+			"moves": ["h8", "a1", "h9", "a2", "h10", "a3", "h11", "a4", "h12", "a5"]}`,
+		`{"rule": "Gomoku-Swap", "board_size": 15, "moves": ["h8", "h9", "h10", "a1"]}`,
+		`{"rule": "StandardGomoku", "board_size": 15, "moves": ["h8"],
+			"choices": [{"step": 1, "choice": "Black"}]}`,
+		`{"rule": "Unknown", "board_size": 15, "moves": []}`,
+		`{"rule": "StandardGomoku", "board_size": 30, "moves": []}`,
+	}
+	for i, record := range records {
+		game, err := LoadGame(strings.NewReader(record), nil)
+		if err == nil {
+			game.TearDown()
+			t.Errorf("no error for record %d", i)
+			continue
+		}
+		t.Logf("record %d: %v", i, err)
+	}
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// Runner drives a game between two players until it's over.
+type Runner struct {
+	Game *Game
+	// Players of Black and White. Under swap openings, they are the first and
+	// the second players, and play the other colors while the color choices
+	// of Game swap them, see Game.IsColorSwapped. Players making color
+	// choices should be ColorChoosers.
+	Players [2]Player
+
+	// Called after each move and color choice, if not nil.
+	OnMove   func(g *Game, pos board.Position)
+	OnChoice func(g *Game, choice ColorChoice)
+}
+
+// Run the game until it's over, ctx is done, or a player returns an error.
+// If Game is replaced during a call of a player, e.g. a record is loaded,
+// the result of the call is dropped and the new game continues.
+func (r *Runner) Run(ctx context.Context) error {
+	for {
+		g := r.Game
+		if g.IsTerminal() {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		color := g.NextPlayer()
+		i := 0
+		if color == board.White {
+			i = 1
+		}
+		// Read from the choices on every turn, as undoing a choice also
+		// takes back its swap.
+		if g.IsColorSwapped() {
+			i = 1 - i
+		}
+		player := r.Players[i]
+		if player == nil {
+			return fmt.Errorf("player of %v is nil", color)
+		}
+		if g.Phase.IsColorChoice() {
+			chooser, ok := player.(ColorChooser)
+			if !ok {
+				return fmt.Errorf("player of %v cannot choose color", color)
+			}
+			choice, err := chooser.ChooseColor(ctx, g)
+			if err != nil {
+				return err
+			}
+			if r.Game != g {
+				continue
+			}
+			err = g.Choose(choice)
+			if err != nil {
+				return err
+			}
+			if r.OnChoice != nil {
+				r.OnChoice(g, choice)
+			}
+			continue
+		}
+		pos, err := player.NextMove(ctx, g)
+		if err != nil {
+			return err
+		}
+		if r.Game != g {
+			continue
+		}
+		err = g.PlaceByUser(pos)
+		if err != nil {
+			return err
+		}
+		for _, p := range r.Players {
+			if p != nil {
+				p.Notify(pos)
+			}
+		}
+		if r.OnMove != nil {
+			r.OnMove(g, pos)
+		}
+	}
+}
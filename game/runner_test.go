@@ -0,0 +1,205 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/donyori/goctpf"
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestRunnerRandomAndScripted(t *testing.T) {
+	for _, rule := range []rules.Rule{rules.StandardGomoku, rules.GomokuSwap2} {
+		settings := NewSettings()
+		settings.Rule = rule
+		settings.Ai.PonderTimeLimit = 0
+		game, err := NewGame(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &Runner{
+			Game:    game,
+			Players: [2]Player{NewRandomPlayer(1), NewRandomPlayer(2)},
+		}
+		var numMove int
+		r.OnMove = func(g *Game, pos board.Position) {
+			numMove++
+		}
+		err = r.Run(context.Background())
+		if err != nil {
+			game.TearDown()
+			t.Fatal(rule, err)
+		}
+		if !game.IsTerminal() || numMove != len(game.History) {
+			t.Errorf("%v: terminal: %t, number of moves: %d, history: %v",
+				rule, game.IsTerminal(), numMove, game.History)
+		}
+		t.Logf("%v: winner: %v, choices: %v", rule, game.Outcome,
+			game.Choices)
+		gr, err := game.Record()
+		game.TearDown()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Replay the game by scripted players.
+		script, err := NewScriptedPlayer(gr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replayed, err := NewGame(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = &Runner{Game: replayed, Players: [2]Player{script, script}}
+		err = r.Run(context.Background())
+		if err != nil {
+			replayed.TearDown()
+			t.Fatal(rule, err)
+		}
+		if !reflect.DeepEqual(replayed.History, game.History) ||
+			!reflect.DeepEqual(replayed.Choices, game.Choices) ||
+			replayed.Outcome != game.Outcome {
+			t.Errorf("%v: replayed history: %v, choices: %v, want %v, %v",
+				rule, replayed.History, replayed.Choices, game.History,
+				game.Choices)
+		}
+		replayed.TearDown()
+	}
+}
+
+func TestAiPlayerOwnGame(t *testing.T) {
+	settings := NewSettings()
+	settings.Ai.PonderTimeLimit = 0
+	game, err := NewGame(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer game.TearDown()
+	ai := *settings.Ai
+	ai.MctsNumSim = 100
+	p := &AiPlayer{Ai: &ai, Worker: &goctpf.WorkerSettings{Number: 1}}
+	defer p.Close()
+	r := &Runner{Game: game, Players: [2]Player{p, NewRandomPlayer(1)}}
+	err = r.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("Winner:", game.Outcome, "history:", game.History)
+	if !reflect.DeepEqual(p.game.History, game.History[:len(p.game.History)]) {
+		t.Errorf("history of the player: %v, of the game: %v",
+			p.game.History, game.History)
+	}
+	// Follow the game after an undo.
+	err = game.Undo(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.NextPlayer() != board.Black {
+		err = game.Undo(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pos, err := p.NextMove(context.Background(), game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.LookupPiece(pos) != 0 ||
+		!reflect.DeepEqual(p.game.History, game.History) {
+		t.Errorf("position: %v, history of the player: %v, of the game: %v",
+			pos, p.game.History, game.History)
+	}
+}
+
+func TestRunnerUndoColorChoice(t *testing.T) {
+	cases := []struct {
+		rule     rules.Rule
+		moves    []string
+		choices  []ChoiceRecord
+		undoStep uint
+		want     []string
+	}{
+		{
+			rules.GomokuSwap,
+			[]string{"h8", "h9", "h10", "a1"},
+			[]ChoiceRecord{{Step: 3, Choice: ChooseBlack}},
+			3,
+			[]string{"p0 move 0", "p0 move 1", "p0 move 2", "p1 choice 3",
+				"p0 move 3", "p1 choice 3", "p0 move 3", "p1 move 4"},
+		},
+		{
+			rules.GomokuSwap2,
+			[]string{"h8", "h9", "h10", "i8", "i9", "a1"},
+			[]ChoiceRecord{{Step: 3, Choice: PlaceTwoMore},
+				{Step: 5, Choice: ChooseWhite}},
+			5,
+			[]string{"p0 move 0", "p0 move 1", "p0 move 2", "p1 choice 3",
+				"p1 move 3", "p1 move 4", "p0 choice 5", "p0 move 5",
+				"p0 choice 5", "p0 move 5", "p1 move 6"},
+		},
+	}
+	for _, c := range cases {
+		settings := NewSettings()
+		settings.Rule = c.rule
+		settings.Ai.PonderTimeLimit = 0
+		game, err := NewGame(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		script := &ScriptedPlayer{Choices: c.choices}
+		for _, s := range c.moves {
+			pos, err := board.ParsePosition(s)
+			if err != nil {
+				game.TearDown()
+				t.Fatal(err)
+			}
+			script.Moves = append(script.Moves, pos)
+		}
+		var log []string
+		r := &Runner{Game: game, Players: [2]Player{
+			&undoingPlayer{ScriptedPlayer: script, name: "p0",
+				undoStep: c.undoStep, log: &log},
+			&undoingPlayer{ScriptedPlayer: script, name: "p1", log: &log},
+		}}
+		err = r.Run(context.Background())
+		game.TearDown()
+		if err != ErrScriptEnded {
+			t.Errorf("%v: error: %v, want %v", c.rule, err, ErrScriptEnded)
+		}
+		if !reflect.DeepEqual(log, c.want) {
+			t.Errorf("%v: calls: %q, want %q", c.rule, log, c.want)
+		}
+	}
+}
+
+// undoingPlayer plays by script, and logs the calls. It undoes the last move
+// once when asked to move at undoStep, like the user on the console does.
+type undoingPlayer struct {
+	*ScriptedPlayer
+	name     string
+	undoStep uint // 0 for never.
+	log      *[]string
+}
+
+func (p *undoingPlayer) NextMove(ctx context.Context, g *Game) (
+	board.Position, error) {
+	*p.log = append(*p.log, fmt.Sprintf("%s move %d", p.name, g.Step()))
+	if p.undoStep > 0 && g.Step() == p.undoStep {
+		p.undoStep = 0
+		err := g.Undo(1)
+		if err != nil {
+			return board.InvalidPosition, err
+		}
+	}
+	return p.ScriptedPlayer.NextMove(ctx, g)
+}
+
+func (p *undoingPlayer) ChooseColor(ctx context.Context, g *Game) (
+	ColorChoice, error) {
+	*p.log = append(*p.log, fmt.Sprintf("%s choice %d", p.name, g.Step()))
+	return p.ScriptedPlayer.ChooseColor(ctx, g)
+}
@@ -0,0 +1,69 @@
+package game
+
+import (
+	"time"
+
+	"github.com/donyori/goctpf"
+
+	"github.com/donyori/ucashw_gt_gomoku/alphabeta"
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/book"
+	"github.com/donyori/ucashw_gt_gomoku/mcts"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+	"github.com/donyori/ucashw_gt_gomoku/solver"
+)
+
+// Engines of AI to place stones, for AiSettings.Engine.
+const (
+	MctsEngine      string = "mcts"
+	AlphaBetaEngine string = "alphabeta"
+)
+
+type AiSettings struct {
+	AiPiece board.Piece `json:"ai_piece,omitempty"`
+	// Engine of PlaceByAi, MctsEngine or AlphaBetaEngine.
+	// Empty for MctsEngine. Color choices and analyses always use
+	// Monte Carlo tree search.
+	Engine string `json:"engine,omitempty"`
+	mcts.Settings
+	// Settings of AlphaBetaEngine. Nil for the default settings.
+	AlphaBeta *alphabeta.Settings `json:"alpha_beta,omitempty"`
+	// Settings of the VCF and VCT search before the engine's search.
+	// Nil for the default settings. Its time limit is ignored if MctsNumSim
+	// is set, see solver.Settings.MaxNode.
+	Solver *solver.Settings `json:"solver,omitempty"`
+	// Settings of the opening book consulted before the search.
+	// Nil for the default settings.
+	Book         *book.Settings `json:"book,omitempty"`
+	BalanceThold float64        `json:"balance_thold,omitempty"`
+	// Maximum time to search during each turn of the user.
+	// Not positive to disable pondering. Pondering is also disabled if Seed
+	// is set, as the tree it grows depends on the user's thinking time,
+	// or if Engine is AlphaBetaEngine, which doesn't reuse the tree.
+	PonderTimeLimit time.Duration `json:"ponder_time_limit,omitempty"`
+}
+
+type Settings struct {
+	Rule      rules.Rule             `json:"rule,omitempty"`
+	BoardSize int                    `json:"board_size,omitempty"`
+	Ai        *AiSettings            `json:"ai,omitempty"`
+	Worker    *goctpf.WorkerSettings `json:"worker,omitempty"`
+}
+
+func NewSettings() *Settings {
+	return &Settings{
+		Rule:      rules.StandardGomoku,
+		BoardSize: board.DefaultBoardSize,
+		Ai: &AiSettings{
+			AiPiece:         board.White,
+			Engine:          MctsEngine,
+			Settings:        *mcts.NewSettings(),
+			AlphaBeta:       alphabeta.NewSettings(),
+			Solver:          solver.NewSettings(),
+			Book:            book.NewSettings(),
+			BalanceThold:    .05,
+			PonderTimeLimit: time.Minute,
+		},
+		Worker: goctpf.NewWorkerSettings(),
+	}
+}
@@ -0,0 +1,41 @@
+package game
+
+import (
+	"context"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/solver"
+)
+
+// Search a forced win of the player to move by VCF and VCT,
+// with Settings.Ai.Solver.
+// Return the winning line, which starts with the next move, or nil if
+// not found. See solver.Solve for details.
+func (g *Game) Solve(ctx context.Context) ([]board.Position, error) {
+	err := g.checkPlace()
+	if err != nil {
+		return nil, err
+	}
+	err = g.StopPondering()
+	if err != nil {
+		return nil, err
+	}
+	return solver.Solve(ctx, g.Settings.Rule, g.Board, g.Step()+1,
+		g.Settings.Ai.Solver)
+}
+
+// Return the settings of the solver before the AI's search.
+// If Settings.Ai.MctsNumSim is set, the time limit of the solver is ignored,
+// so that the same seed and settings produce the same moves.
+func (g *Game) aiSolverSettings() *solver.Settings {
+	ss := g.Settings.Ai.Solver
+	if ss == nil {
+		ss = solver.NewSettings()
+	}
+	if g.Settings.Ai.MctsNumSim > 0 && ss.TimeLimit > 0 {
+		s := *ss
+		s.TimeLimit = 0
+		ss = &s
+	}
+	return ss
+}
@@ -0,0 +1,146 @@
+// Package match plays matches between two AIs, e.g. of different engines or
+// settings, and estimates their Elo difference.
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+)
+
+type Settings struct {
+	// Settings of the first and the second AIs.
+	// Nil for the AI settings of the games.
+	Ai      [2]*game.AiSettings `json:"ai"`
+	NumGame int                 `json:"num_game"`
+	// Opening positions, each a sequence of moves separated by spaces,
+	// e.g. "H8 I9 J10". The AIs play each opening twice, swapping colors.
+	// Empty to start from the empty board.
+	Openings []string `json:"openings,omitempty"`
+	// Settings of the SPRT to stop the match early. Nil to play all games.
+	Sprt *SprtSettings `json:"sprt,omitempty"`
+}
+
+// A game of a match.
+type GameSummary struct {
+	// 0-based.
+	Index   int
+	Opening string
+	// Color of the first AI at the end of the game, after color choices.
+	FirstAiPiece board.Piece
+	// 0 for a draw.
+	Winner  board.Piece
+	History []board.Position
+}
+
+// Play the games of the match in turn, until Settings.NumGame games are
+// played, the SPRT accepts a hypothesis, or ctx is done.
+// The AIs take turns to be the first player.
+// The rule, board size and workers of the games are in gameSettings.
+// If onGame is not nil, it's called after each game with the result so far.
+// Return the result and the decision of the SPRT, which is Continue if
+// the SPRT is disabled. If ctx is done, the result so far is returned with
+// ctx.Err().
+func Run(ctx context.Context, gameSettings *game.Settings, settings *Settings,
+	onGame func(gs *GameSummary, r *Result)) (*Result, SprtDecision, error) {
+	if gameSettings == nil {
+		gameSettings = game.NewSettings()
+	}
+	if settings == nil {
+		return nil, Continue, errors.New("settings is nil")
+	}
+	gs := *gameSettings
+	if gs.Ai == nil {
+		gs.Ai = game.NewSettings().Ai
+	}
+	ai := *gs.Ai
+	ai.AiPiece = board.Both
+	ai.PonderTimeLimit = 0
+	gs.Ai = &ai
+	var ais [2]*game.AiSettings
+	for i := range ais {
+		ais[i] = settings.Ai[i]
+		if ais[i] == nil {
+			ais[i] = gameSettings.Ai
+		}
+	}
+
+	r := new(Result)
+	for i := 0; i < settings.NumGame; i++ {
+		var opening string
+		if len(settings.Openings) > 0 {
+			opening = settings.Openings[i/2%len(settings.Openings)]
+		}
+		gr, err := playGame(ctx, &gs, ais, i, opening)
+		if err != nil {
+			return r, Continue, err
+		}
+		switch gr.Winner {
+		case gr.FirstAiPiece:
+			r.Wins++
+		case 0:
+			r.Draws++
+		default:
+			r.Losses++
+		}
+		if onGame != nil {
+			onGame(gr, r)
+		}
+		if settings.Sprt != nil {
+			if d := r.Sprt(settings.Sprt); d != Continue {
+				return r, d, nil
+			}
+		}
+	}
+	return r, Continue, nil
+}
+
+func playGame(ctx context.Context, gs *game.Settings, ais [2]*game.AiSettings,
+	index int, opening string) (*GameSummary, error) {
+	g, err := game.NewGame(gs)
+	if err != nil {
+		return nil, err
+	}
+	defer g.TearDown()
+	for _, s := range strings.Fields(opening) {
+		pos, err := board.ParsePosition(s)
+		if err == nil {
+			err = g.PlaceByUser(pos)
+		}
+		if err == nil && g.Phase.IsColorChoice() {
+			err = errors.New("color choice is in the opening")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("opening %q: %v", opening, err)
+		}
+	}
+	first := &game.AiPlayer{Ai: ais[0], Worker: gs.Worker}
+	defer first.Close()
+	second := &game.AiPlayer{Ai: ais[1], Worker: gs.Worker}
+	defer second.Close()
+	runner := &game.Runner{Game: g, Players: [2]game.Player{first, second}}
+	if index%2 == 1 {
+		runner.Players[0], runner.Players[1] = second, first
+	}
+	err = runner.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gr := &GameSummary{
+		Index:        index,
+		Opening:      opening,
+		FirstAiPiece: board.White,
+		History:      g.History,
+	}
+	if (runner.Players[0] == game.Player(first)) != g.IsColorSwapped() {
+		gr.FirstAiPiece = board.Black
+	}
+	if g.Outcome == board.Black || g.Outcome == board.White {
+		gr.Winner = g.Outcome
+	}
+	return gr, nil
+}
@@ -0,0 +1,97 @@
+package match
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/donyori/goctpf"
+
+	"github.com/donyori/ucashw_gt_gomoku/alphabeta"
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/solver"
+)
+
+func TestResultElo(t *testing.T) {
+	cases := []struct {
+		r    Result
+		want float64
+	}{
+		{Result{Wins: 10, Losses: 10}, 0.},
+		{Result{Wins: 5, Losses: 5, Draws: 10}, 0.},
+		{Result{Wins: 60, Losses: 40}, 70.437},
+		{Result{Wins: 40, Losses: 60}, -70.437},
+	}
+	for _, c := range cases {
+		diff, margin := c.r.Elo()
+		if math.Abs(diff-c.want) > 1e-3 || !(margin > 0.) {
+			t.Errorf("%+v: Elo %f ± %f, want %f", c.r, diff, margin, c.want)
+		}
+	}
+	r := Result{Wins: 3}
+	if diff, _ := r.Elo(); !math.IsInf(diff, 1) {
+		t.Errorf("%+v: Elo %f, want +Inf", r, diff)
+	}
+}
+
+func TestResultSprt(t *testing.T) {
+	s := NewSprtSettings()
+	cases := []struct {
+		r    Result
+		want SprtDecision
+	}{
+		{Result{}, Continue},
+		{Result{Wins: 6, Losses: 4}, Continue},
+		{Result{Wins: 600, Losses: 400}, AcceptH1},
+		{Result{Wins: 400, Losses: 600}, AcceptH0},
+		{Result{Draws: 10}, Continue},
+		{Result{Wins: 1}, Continue},
+		{Result{Losses: 20}, AcceptH0},
+		{Result{Wins: 20}, AcceptH1},
+	}
+	for _, c := range cases {
+		if d := c.r.Sprt(s); d != c.want {
+			t.Errorf("%+v: %v (LLR: %f), want %v", c.r, d, c.r.Llr(s), c.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	gs := game.NewSettings()
+	gs.Worker = &goctpf.WorkerSettings{Number: 1}
+	var ais [2]*game.AiSettings
+	for i := range ais {
+		ai := *gs.Ai
+		ai.MctsNumSim = 20
+		ai.AlphaBeta = &alphabeta.Settings{MaxDepth: 2, NumCandidate: 8}
+		ai.Solver = &solver.Settings{VcfDepth: 4}
+		ais[i] = &ai
+	}
+	ais[1].Engine = game.AlphaBetaEngine
+	settings := &Settings{
+		Ai:       ais,
+		NumGame:  2,
+		Openings: []string{"H8 I9"},
+	}
+	var pieces []board.Piece
+	r, d, err := Run(context.Background(), gs, settings,
+		func(gs *GameSummary, r *Result) {
+			t.Logf("game %d, first AI: %v, winner: %v, moves: %d", gs.Index,
+				gs.FirstAiPiece, gs.Winner, len(gs.History))
+			pieces = append(pieces, gs.FirstAiPiece)
+			if len(gs.History) < 2 || gs.History[1].String() != "I9" {
+				t.Errorf("history: %v, want the opening first", gs.History)
+			}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.NumGame() != 2 || d != Continue {
+		t.Errorf("result: %+v, decision: %v", r, d)
+	}
+	if len(pieces) != 2 || pieces[0] != board.Black ||
+		pieces[1] != board.White {
+		t.Errorf("colors of the first AI: %v", pieces)
+	}
+}
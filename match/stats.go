@@ -0,0 +1,141 @@
+package match
+
+import "math"
+
+// Quantile of the standard normal distribution for the 95% error bars.
+const normalQuantile95 float64 = 1.959964
+
+// Outcomes of the games of a match, for the first AI.
+type Result struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+func (r *Result) NumGame() int {
+	return r.Wins + r.Losses + r.Draws
+}
+
+// Return the average score of the first AI, 1 for a win and 0.5 for a draw.
+// Return NaN if no game is played.
+func (r *Result) Score() float64 {
+	n := r.NumGame()
+	if n == 0 {
+		return math.NaN()
+	}
+	return (float64(r.Wins) + float64(r.Draws)/2.) / float64(n)
+}
+
+// Return the estimated Elo difference of the first AI over the second AI,
+// and the margin of its 95% error bars.
+// The difference and the margin are infinite if one AI wins all games.
+func (r *Result) Elo() (diff, margin float64) {
+	score := r.Score()
+	diff = eloOfScore(score)
+	if math.IsInf(diff, 0) {
+		return diff, math.Inf(1)
+	}
+	stderr := math.Sqrt(r.scoreVariance() / float64(r.NumGame()))
+	high := eloOfScore(math.Min(score+normalQuantile95*stderr, 1.))
+	low := eloOfScore(math.Max(score-normalQuantile95*stderr, 0.))
+	return diff, (high - low) / 2.
+}
+
+// Return the log-likelihood ratio of the hypothesis that the Elo difference
+// is s.Elo1 against that it is s.Elo0, by the normal approximation of
+// the scores. If all games are won or lost, a virtual draw is counted so
+// that the scores vary. Return 0 if no game is played, or all are drawn.
+func (r *Result) Llr(s *SprtSettings) float64 {
+	if r.NumGame() == r.Draws {
+		return 0.
+	}
+	variance := r.scoreVariance()
+	if variance == 0. {
+		rr := *r
+		rr.Draws++
+		return rr.Llr(s)
+	}
+	s0, s1 := scoreOfElo(s.Elo0), scoreOfElo(s.Elo1)
+	return float64(r.NumGame()) * (s1 - s0) * (2.*r.Score() - s0 - s1) /
+		(2. * variance)
+}
+
+// Return the decision of the SPRT by the games so far.
+func (r *Result) Sprt(s *SprtSettings) SprtDecision {
+	llr := r.Llr(s)
+	lower, upper := s.Bounds()
+	switch {
+	case llr >= upper:
+		return AcceptH1
+	case llr <= lower:
+		return AcceptH0
+	default:
+		return Continue
+	}
+}
+
+// Return the variance of the score of each game.
+func (r *Result) scoreVariance() float64 {
+	n := r.NumGame()
+	if n == 0 {
+		return 0.
+	}
+	score := r.Score()
+	return (float64(r.Wins)*(1.-score)*(1.-score) +
+		float64(r.Draws)*(.5-score)*(.5-score) +
+		float64(r.Losses)*score*score) / float64(n)
+}
+
+// Sequential probability ratio test of the Elo difference, to stop a match
+// as soon as it's clear enough.
+type SprtSettings struct {
+	// Elo differences of the null hypothesis H0 and the alternative one H1.
+	Elo0 float64 `json:"elo0"`
+	Elo1 float64 `json:"elo1"`
+	// Probabilities of accepting H1 when H0 is true, and the opposite.
+	Alpha float64 `json:"alpha"`
+	Beta  float64 `json:"beta"`
+}
+
+func NewSprtSettings() *SprtSettings {
+	return &SprtSettings{
+		Elo0:  0.,
+		Elo1:  10.,
+		Alpha: .05,
+		Beta:  .05,
+	}
+}
+
+// Return the bounds of the log-likelihood ratio to accept H0 and H1.
+func (s *SprtSettings) Bounds() (lower, upper float64) {
+	return math.Log(s.Beta / (1. - s.Alpha)), math.Log((1. - s.Beta) / s.Alpha)
+}
+
+type SprtDecision int8
+
+const (
+	Continue SprtDecision = iota
+	AcceptH0
+	AcceptH1
+)
+
+var sprtDecisionStrings = [...]string{
+	Continue: "Continue",
+	AcceptH0: "H0 accepted",
+	AcceptH1: "H1 accepted",
+}
+
+func (d SprtDecision) String() string {
+	if d < 0 || int(d) >= len(sprtDecisionStrings) {
+		return "Unknown"
+	}
+	return sprtDecisionStrings[d]
+}
+
+func eloOfScore(score float64) float64 {
+	return -400. * math.Log10(1./score-1.)
+}
+
+func scoreOfElo(elo float64) float64 {
+	return 1. / (1. + math.Pow(10., -elo/400.))
+}
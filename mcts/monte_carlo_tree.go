@@ -0,0 +1,694 @@
+package mcts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/donyori/goctpf"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// Node of a Monte Carlo tree.
+type Node struct {
+	Tree *Tree
+
+	Parent, PrevSibling, LastChild *Node
+
+	Step uint
+	Pos  board.Position
+
+	// Updated atomically during the search.
+	NumWin uint64
+	NumSim uint64
+	// All-moves-as-first statistics, see AmafWinRate.
+	NumAmafWin uint64
+	NumAmafSim uint64
+	// Number of the searches passing this node but not backpropagated yet,
+	// counted as losses in Uct, so that parallel searches spread out.
+	numVirtualLoss int64
+	// Proof of the node, updated atomically.
+	proof int32
+
+	// Lock on unexpPos and LastChild during the search.
+	mu       sync.Mutex
+	unexpPos []board.Position
+}
+
+// Return the board of this node, i.e. a copy of the board at the root with
+// the stones placed from the root(exclusive) to this node(inclusive).
+func (mctn *Node) Board() *board.Board {
+	if mctn == nil {
+		return nil
+	}
+	b := mctn.Tree.Board.Copy()
+	for node := mctn; node != nil && node.Parent != nil; node = node.Parent {
+		b.Set(node.Pos, board.PieceOfStep(node.Step))
+	}
+	return b
+}
+
+func (mctn *Node) IsTerminal() bool {
+	if mctn == nil {
+		return true
+	}
+	mctn.mu.Lock()
+	defer mctn.mu.Unlock()
+	return len(mctn.unexpPos) == 0 && mctn.LastChild == nil
+}
+
+// Return the child simulated the most times. But a proven win comes first,
+// and proven losses come last.
+func (mctn *Node) GetBestNumSimChild() *Node {
+	if mctn == nil || mctn.LastChild == nil {
+		return nil
+	}
+	best := mctn.LastChild
+	bestRank := best.proofRank()
+	var n float64 = 1.
+	for node := best.PrevSibling; node != nil; node = node.PrevSibling {
+		rank := node.proofRank()
+		if rank > bestRank || (rank == bestRank && node.NumSim > best.NumSim) {
+			n = 1.
+			best = node
+			bestRank = rank
+		} else if rank == bestRank && node.NumSim == best.NumSim {
+			// Pick one of the best NumSim children randomly, with equal probability.
+			n++
+			if mctn.Tree.rng.Float64() < 1./n {
+				best = node
+			}
+		}
+	}
+	return best
+}
+
+// Return the child whose win rate is the closest to 50%, among the children
+// simulated at least 1/10 as many times as the best NumSim child.
+func (mctn *Node) GetMostBalancedChild() *Node {
+	mostSim := mctn.GetBestNumSimChild()
+	if mostSim == nil || mostSim.NumSim == 0 {
+		return mostSim
+	}
+	best := mostSim
+	bestDiff := math.Abs(best.WinRate() - .5)
+	for node := mctn.LastChild; node != nil; node = node.PrevSibling {
+		if node.NumSim*10 < mostSim.NumSim || node.Proof() == ProvenLoss {
+			continue
+		}
+		diff := math.Abs(node.WinRate() - .5)
+		if diff < bestDiff {
+			best = node
+			bestDiff = diff
+		}
+	}
+	return best
+}
+
+// Return the rate of simulations won by the player who placed the stone
+// of this node.
+func (mctn *Node) WinRate() float64 {
+	if mctn == nil || mctn.NumSim == 0 {
+		return 0.
+	}
+	return float64(mctn.NumWin) / float64(mctn.NumSim)
+}
+
+// Order of the proofs for choosing a child: win, not proven or draw, loss.
+func (mctn *Node) proofRank() int {
+	switch mctn.Proof() {
+	case ProvenWin:
+		return 2
+	case ProvenLoss:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Upper Confidence Bound 1 applied to trees, with virtual losses counted,
+// and the win rate blended with RAVE if Settings.RaveEquivalence is positive.
+func (mctn *Node) Uct() float64 {
+	if mctn == nil {
+		return 0.
+	}
+	n := float64(mctn.numSimWithVirtualLoss())
+	if n == 0. || mctn.Parent == nil {
+		return math.Inf(1)
+	}
+	w := float64(atomic.LoadUint64(&mctn.NumWin))
+	nParent := float64(mctn.Parent.numSimWithVirtualLoss())
+	return mctn.raveWinRate(w, n) +
+		mctn.Tree.Settings.UctParamC*math.Sqrt(math.Log(nParent)/n)
+}
+
+func (mctn *Node) numSimWithVirtualLoss() uint64 {
+	return atomic.LoadUint64(&mctn.NumSim) +
+		uint64(atomic.LoadInt64(&mctn.numVirtualLoss))
+}
+
+func (mctn *Node) GetBestUctChild() (*Node, error) {
+	if mctn == nil {
+		return nil, nil
+	}
+	return mctn.getBestUctChild(mctn.Tree.rng)
+}
+
+// rng is used to break ties.
+// Proven losses are skipped, so it returns nil if all children are.
+func (mctn *Node) getBestUctChild(rng *rand.Rand) (*Node, error) {
+	// Take the children first, as they may be expanded meanwhile.
+	var children []*Node
+	mctn.mu.Lock()
+	for node := mctn.LastChild; node != nil; node = node.PrevSibling {
+		if node.Proof() != ProvenLoss {
+			children = append(children, node)
+		}
+	}
+	mctn.mu.Unlock()
+	if len(children) == 0 {
+		return nil, nil
+	}
+	numChild := len(children)
+	tg := goctpf.NewTaskGroup(nil, nil)
+	outputChan := make(chan *NodeAndUct, numChild)
+	for i, node := range children {
+		err := mctn.Tree.SubmitCalcUctTask(tg.WrapTask(&CalcUctTask{
+			Node:   node,
+			Output: outputChan,
+			Index:  i,
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := mctn.Tree.SubmitWaitAndCloseTask(&WaitAndCloseTask{
+		WaitTgt:  tg,
+		CloseTgt: outputChan,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cmpThold := mctn.Tree.Settings.UctCmpThold
+	if cmpThold == 0. {
+		cmpThold = Epsilon
+	}
+	// The outputs arrive in any order. Put them in the order of the children,
+	// so that the random pick below is reproducible with the same seed.
+	outputs := make([]*NodeAndUct, numChild)
+	for output := range outputChan {
+		outputs[output.Index] = output
+	}
+	best := &NodeAndUct{Uct: -math.MaxFloat64}
+	var n float64
+	// For debug:
+	//fmt.Println("Waiting for output")
+	for _, output := range outputs {
+		// For debug:
+		//fmt.Printf("best %#v\n", best)
+		if output.Uct > best.Uct+cmpThold {
+			// For debug:
+			//fmt.Printf("Goin case 1, output: %#v\n", output)
+			n = 1.
+			best = output
+		} else if output.Uct > best.Uct-cmpThold {
+			// For debug:
+			//fmt.Printf("Goin case 2, output: %#v\n", output)
+			// Pick one of the best UCT children randomly, with equal probability.
+			n++
+			if rng.Float64() < 1./n {
+				// For debug:
+				//fmt.Println("Update in case 2.")
+				best = output
+			}
+		}
+	}
+	return best.Node, nil
+}
+
+func (mctn *Node) IsFullyExpanded() bool {
+	if mctn == nil {
+		return true
+	}
+	mctn.mu.Lock()
+	defer mctn.mu.Unlock()
+	return len(mctn.unexpPos) == 0
+}
+
+func (mctn *Node) Expand() (*Node, error) {
+	if mctn.IsFullyExpanded() {
+		return nil, nil
+	}
+	return mctn.expand(mctn.Board(), mctn.Tree.rng)
+}
+
+// b is the board of mctn, and will be updated to the board of the new child.
+// rng is used to shuffle the unexpanded positions of the new child.
+// Return nil if mctn is fully expanded, and b is not changed.
+func (mctn *Node) expand(b *board.Board, rng *rand.Rand) (*Node, error) {
+	if mctn == nil {
+		return nil, nil
+	}
+	mctn.mu.Lock()
+	defer mctn.mu.Unlock()
+	if len(mctn.unexpPos) == 0 {
+		return nil, nil
+	}
+	last := len(mctn.unexpPos) - 1
+	pos := mctn.unexpPos[last]
+
+	node := &Node{
+		Tree:        mctn.Tree,
+		Parent:      mctn,
+		PrevSibling: mctn.LastChild,
+		Step:        mctn.Step + 1,
+		Pos:         pos,
+	}
+	b.Set(pos, board.PieceOfStep(node.Step))
+	piece := mctn.Tree.CheckOutcome(b, pos)
+	switch piece {
+	case 0, board.Both:
+		node.unexpPos = mctn.Tree.getValidPositions(b, node.Step+1, rng)
+		if len(node.unexpPos) == 0 {
+			node.proof = int32(ProvenDraw)
+		}
+	case board.Black, board.White:
+		node.unexpPos = nil
+		node.proof = int32(ProvenWin)
+	default:
+		return nil, fmt.Errorf("cannot check outcome on position %v", pos)
+	}
+
+	mctn.LastChild = node
+	mctn.unexpPos[last] = board.InvalidPosition
+	if last > 0 {
+		mctn.unexpPos = mctn.unexpPos[:last]
+	} else {
+		mctn.unexpPos = nil
+	}
+	return node, nil
+}
+
+func (mctn *Node) Rollout() board.Piece {
+	if mctn == nil {
+		return board.InvalidPiece
+	}
+	return mctn.rollout(mctn.Board(), mctn.Tree.rng, nil)
+}
+
+// b is the board of mctn, and will be modified by the rollout.
+// If played is not nil, the steps of the stones placed are recorded in it,
+// indexed by position.
+func (mctn *Node) rollout(b *board.Board, rng *rand.Rand,
+	played []uint16) board.Piece {
+	if mctn.IsTerminal() {
+		return mctn.Tree.CheckOutcome(b, mctn.Pos)
+	}
+	step := mctn.Step
+	var outcome board.Piece
+	for outcome == 0 {
+		step++
+		vps := mctn.Tree.GetValidPositions(b, step, false)
+		if len(vps) == 0 {
+			// Outcome is draw.
+			return 0
+		}
+		pos := mctn.Tree.RolloutPolicy.Pick(mctn.Tree.Rule, b, step, vps, rng)
+		b.Set(pos, board.PieceOfStep(step))
+		if played != nil {
+			played[pos] = uint16(step)
+		}
+		outcome = mctn.Tree.CheckOutcome(b, pos)
+	}
+	return outcome
+}
+
+func (mctn *Node) BackPropagate(outcome board.Piece) error {
+	var isWin bool
+	switch outcome {
+	case 0, board.Both:
+		outcome = 0
+	case board.Black:
+		isWin = mctn.Step%2 == 1
+	case board.White:
+		isWin = mctn.Step%2 == 0
+	default:
+		return fmt.Errorf("outcome(%b) is invalid", outcome)
+	}
+	for node := mctn; node != nil; node = node.Parent {
+		if isWin {
+			atomic.AddUint64(&node.NumWin, 1)
+		}
+		atomic.AddUint64(&node.NumSim, 1)
+		if outcome != 0 {
+			isWin = !isWin
+		}
+	}
+	mctn.propagateProof()
+	return nil
+}
+
+// Detach the node from its parent, to make it a root.
+// Like AttachTo, it must not be called during the search.
+func (mctn *Node) TakeOut() {
+	if mctn == nil || mctn.Parent == nil {
+		// Is nil or already as root, just return.
+		return
+	}
+	parent := mctn.Parent
+	sibling := mctn.PrevSibling
+	mctn.Parent = nil
+	mctn.PrevSibling = nil
+	child := parent.LastChild
+	if child == mctn {
+		parent.LastChild = sibling
+		return
+	}
+	s := child.PrevSibling
+	for s != mctn {
+		child = s
+		s = child.PrevSibling
+	}
+	if child == nil {
+		return
+	}
+	child.PrevSibling = sibling
+}
+
+// Attach the node to parent as its last child. It's the reverse of TakeOut.
+func (mctn *Node) AttachTo(parent *Node) {
+	if mctn == nil || parent == nil {
+		return
+	}
+	mctn.TakeOut()
+	// Don't expand the same position again.
+	for i, p := range parent.unexpPos {
+		if p == mctn.Pos {
+			last := len(parent.unexpPos) - 1
+			parent.unexpPos[i] = parent.unexpPos[last]
+			parent.unexpPos[last] = board.InvalidPosition
+			if last > 0 {
+				parent.unexpPos = parent.unexpPos[:last]
+			} else {
+				parent.unexpPos = nil
+			}
+			break
+		}
+	}
+	mctn.Parent = parent
+	mctn.PrevSibling = parent.LastChild
+	parent.LastChild = mctn
+}
+
+// Selection and expansion steps of Monte Carlo tree search.
+func (mctn *Node) Traverse() (*Node, error) {
+	if mctn == nil {
+		return nil, nil
+	}
+	return mctn.traverse(mctn.Board(), mctn.Tree.rng, false)
+}
+
+// b is the board of mctn, and will be updated to the board of
+// the returned node.
+// If doesAddVirtualLoss is true, a virtual loss is added to the nodes
+// from mctn to the returned node, which should be removed by
+// removeVirtualLoss after backpropagation.
+func (mctn *Node) traverse(b *board.Board, rng *rand.Rand,
+	doesAddVirtualLoss bool) (*Node, error) {
+	node := mctn
+	for {
+		if doesAddVirtualLoss {
+			atomic.AddInt64(&node.numVirtualLoss, 1)
+		}
+		if node != mctn && node.Proof() != NotProven {
+			// Its outcome is known, no need to search it.
+			return node, nil
+		}
+		// The node may be fully expanded by other searches
+		// since it's selected, so just try to expand it.
+		child, err := node.expand(b, rng)
+		if err != nil {
+			if doesAddVirtualLoss {
+				mctn.removeVirtualLoss(node)
+			}
+			return nil, err
+		}
+		if child != nil {
+			if doesAddVirtualLoss {
+				atomic.AddInt64(&child.numVirtualLoss, 1)
+			}
+			return child, nil
+		}
+		if node.IsTerminal() {
+			return node, nil
+		}
+		next, err := node.getBestUctChild(rng)
+		if err != nil {
+			if doesAddVirtualLoss {
+				mctn.removeVirtualLoss(node)
+			}
+			return nil, err
+		}
+		if next == nil {
+			// All children are proven losses, i.e. node is a proven win.
+			return node, nil
+		}
+		node = next
+		b.Set(node.Pos, board.PieceOfStep(node.Step))
+	}
+}
+
+// Remove the virtual losses added by mctn.traverse from node to mctn.
+func (mctn *Node) removeVirtualLoss(node *Node) {
+	for ; node != mctn.Parent; node = node.Parent {
+		atomic.AddInt64(&node.numVirtualLoss, -1)
+	}
+}
+
+// Perform one simulation(including selection, expansion, rollout and backpropagation)
+//   of Monte Carlo tree search.
+// Return the elapsed time and occured error.
+func (mctn *Node) Simulate() (
+	elapsedTime time.Duration, err error) {
+	if mctn == nil {
+		return
+	}
+	return mctn.simulate(mctn.Tree.rng)
+}
+
+// Same as Simulate, but safe to run in parallel on the same tree,
+// each with its own rng.
+func (mctn *Node) simulate(rng *rand.Rand) (
+	elapsedTime time.Duration, err error) {
+	startTime := time.Now()
+	defer func() {
+		elapsedTime = time.Since(startTime)
+	}()
+	b := mctn.Board()
+	var node *Node
+	node, err = mctn.traverse(b, rng, true)
+	if err != nil {
+		return
+	}
+	defer mctn.removeVirtualLoss(node)
+	var played []uint16
+	if mctn.Tree.Settings.RaveEquivalence > 0. {
+		// Indexed by Position, like the cells of Board.
+		played = make([]uint16, board.NumPosition+1)
+		mctn.recordPath(node, played)
+	}
+	outcome := node.provenOutcome()
+	if outcome == board.InvalidPiece {
+		outcome = node.rollout(b, rng, played)
+	}
+	err = node.BackPropagate(outcome)
+	if err == nil && played != nil {
+		mctn.updateAmaf(node, outcome, played)
+	}
+	return
+}
+
+// Search from mctn until the time limit or the number of simulations
+// in settings is reached, and return the child simulated the most times.
+// The simulations run in parallel, one per worker of the tree.
+func (mctn *Node) MonteCarloTreeSearch() (bestChild *Node, err error) {
+	return mctn.MonteCarloTreeSearchContext(context.Background())
+}
+
+// Same as MonteCarloTreeSearch, but also stop when ctx is done.
+// The best child found so far is returned, with nil error if there is one,
+// otherwise ctx.Err(). The tree is left consistent, as each simulation is
+// either done or not started.
+func (mctn *Node) MonteCarloTreeSearchContext(ctx context.Context) (
+	bestChild *Node, err error) {
+	if mctn == nil {
+		return nil, nil
+	}
+	settings := mctn.Tree.Settings
+	if settings.MctsNumSim > 0 {
+		return mctn.searchWithin(ctx, 0, settings.MctsNumSim)
+	}
+	return mctn.searchWithin(ctx, settings.MctsTimeLimit, 0)
+}
+
+// Same as MonteCarloTreeSearchContext, but search for timeLimit,
+// instead of the limits in the settings.
+func (mctn *Node) MonteCarloTreeSearchFor(ctx context.Context,
+	timeLimit time.Duration) (bestChild *Node, err error) {
+	return mctn.searchWithin(ctx, timeLimit, 0)
+}
+
+// Search until timeLimit if numSimLimit is 0, otherwise until numSimLimit
+// simulations are done, or until ctx is done.
+func (mctn *Node) searchWithin(ctx context.Context, timeLimit time.Duration,
+	numSimLimit uint64) (bestChild *Node, err error) {
+	if mctn == nil || mctn.IsTerminal() {
+		return mctn, nil
+	}
+	if mctn.Proof() != NotProven {
+		return mctn.bestChildSoFar(ctx)
+	}
+	settings := mctn.Tree.Settings
+	var deadline time.Time
+	if numSimLimit == 0 {
+		deadline = time.Now().Add(timeLimit)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	rngs := mctn.Tree.rngs
+	// Root of the search of each worker.
+	roots := make([]*Node, len(rngs))
+	isRootParallel := settings.IsRootParallel && mctn.Parent == nil &&
+		len(rngs) > 1
+	for i := range roots {
+		if isRootParallel {
+			roots[i] = mctn.newRootCopy(rngs[i])
+		} else {
+			roots[i] = mctn
+		}
+	}
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var numSim uint64
+	errs := make([]error, len(rngs))
+	var wg sync.WaitGroup
+	wg.Add(len(rngs))
+	for i := range rngs {
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = roots[i].search(searchCtx, deadline, numSimLimit,
+				&numSim, rngs[i])
+			if errs[i] != nil {
+				// Stop other workers.
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	if isRootParallel {
+		mctn.merge(roots)
+	}
+	return mctn.bestChildSoFar(ctx)
+}
+
+// Return a new root with the same position as mctn, which is a root,
+// for a root-parallel search.
+// rng is used to shuffle its unexpanded positions.
+func (mctn *Node) newRootCopy(rng *rand.Rand) *Node {
+	t := mctn.Tree
+	return &Node{
+		Tree:     t,
+		Step:     mctn.Step,
+		Pos:      mctn.Pos,
+		unexpPos: t.getValidPositions(t.Board, mctn.Step+1, rng),
+	}
+}
+
+// Merge the results of the root-parallel search from roots to mctn.
+// The statistics of the children at the same position are added up.
+// The children not in mctn are attached to mctn with their subtrees,
+// and the subtrees of others are dropped.
+func (mctn *Node) merge(roots []*Node) {
+	children := make(map[board.Position]*Node)
+	for node := mctn.LastChild; node != nil; node = node.PrevSibling {
+		children[node.Pos] = node
+	}
+	for _, root := range roots {
+		mctn.NumWin += root.NumWin
+		mctn.NumSim += root.NumSim
+		for node := root.LastChild; node != nil; {
+			prev := node.PrevSibling
+			if child := children[node.Pos]; child != nil {
+				child.NumWin += node.NumWin
+				child.NumSim += node.NumSim
+				child.NumAmafWin += node.NumAmafWin
+				child.NumAmafSim += node.NumAmafSim
+				if proof := node.Proof(); proof != NotProven {
+					child.setProof(proof)
+				}
+			} else {
+				node.AttachTo(mctn)
+				children[node.Pos] = node
+			}
+			node = prev
+		}
+	}
+	if proof := mctn.checkProof(); proof != NotProven {
+		mctn.setProof(proof)
+	}
+}
+
+// Simulate from mctn until ctx is done, the deadline (if not zero) is
+// reached, or the number of simulations counted by numSim reaches
+// limit (if positive).
+// It's run by each worker of the search.
+func (mctn *Node) search(ctx context.Context, deadline time.Time,
+	limit uint64, numSim *uint64, rng *rand.Rand) error {
+	var n float64
+	var halfAvgElapsedTime float64
+	for {
+		if !deadline.IsZero() &&
+			float64(time.Until(deadline)) <= halfAvgElapsedTime {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		if mctn.Proof() != NotProven {
+			return nil
+		}
+		if limit > 0 && atomic.AddUint64(numSim, 1) > limit {
+			return nil
+		}
+		elapsedTime, err := mctn.simulate(rng)
+		if err != nil {
+			return err
+		}
+		n++
+		halfAvgElapsedTime = (halfAvgElapsedTime*(n-1.) +
+			float64(elapsedTime)/2.) / n
+	}
+}
+
+func (mctn *Node) bestChildSoFar(ctx context.Context) (*Node, error) {
+	best := mctn.GetBestNumSimChild()
+	if best == nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,418 @@
+package mcts
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/donyori/goctpf"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestNodeBoard(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	node, err := root.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("node pos:", node.Pos)
+	isFailed := true
+	b := node.Board()
+	for _, p := range board.GetAllPositions(b.Size()) {
+		if piece := b.Get(p); piece != 0 {
+			t.Logf("node.Board().Get(%v) = %v", p, piece)
+			isFailed = false
+		}
+	}
+	if isFailed {
+		t.Fail()
+	}
+}
+
+func TestNewMonteCarloTreeForNon0Step(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	h8, err := board.ParsePosition("h8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h9, err := board.ParsePosition("h9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Board.Set(h8, board.Black)
+	tree.Board.Set(h9, board.White)
+	newRoot, err := tree.NewNode(root.Step+2, h9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newRoot == nil {
+		t.Fatal("new root is nil")
+	}
+	if newRoot.IsFullyExpanded() {
+		t.Fail()
+	}
+	logMctNodeInfo(t, newRoot)
+}
+
+func TestExpand(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	node, err := root.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.IsFullyExpanded() {
+		t.Fail()
+	}
+	if node == nil {
+		t.Fatal("node is nil")
+	}
+	logMctNodeInfo(t, root)
+	logMctNodeInfo(t, node)
+}
+
+func TestRollout1(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	t.Log(root.Rollout())
+}
+
+func TestRollout2(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	node, err := root.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(node.Rollout())
+}
+
+func TestSimulate(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	et, err := root.Simulate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("Elapsed time:", et)
+	logMctNodeInfo(t, root)
+}
+
+func TestSimulate2Times(t *testing.T) {
+	testSimulateNTimes(t, 2)
+}
+
+func TestSimulate5Times(t *testing.T) {
+	testSimulateNTimes(t, 5)
+}
+
+func TestSimulate225Times(t *testing.T) {
+	testSimulateNTimes(t, 225)
+}
+
+func TestGetBestUctChild(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	for i := 0; i < 225; i++ {
+		_, err := root.Simulate()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	logMctNodeInfo(t, root)
+	//fmt.Println("* Call GetBestUctChild():")
+	bestUctChild, err := root.GetBestUctChild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logMctNodeInfo(t, bestUctChild)
+}
+
+func TestSimulate226Times(t *testing.T) {
+	testSimulateNTimes(t, 226)
+}
+
+func TestSimulate300Times(t *testing.T) {
+	testSimulateNTimes(t, 300)
+}
+
+func TestMonteCarloTreeSearch(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	bestChild, err := root.MonteCarloTreeSearch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logMctNodeInfo(t, root)
+	if bestChild == nil {
+		t.Log("Best child is nil.")
+		return
+	}
+	t.Log("Best child pos:", bestChild.Pos)
+}
+
+func TestMonteCarloTreeSearchContext(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	tree.Settings.MctsTimeLimit = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(),
+		time.Millisecond*100)
+	defer cancel()
+	startTime := time.Now()
+	bestChild, err := root.MonteCarloTreeSearchContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if et := time.Since(startTime); et > time.Second {
+		t.Errorf("elapsed time: %v, deadline not honored", et)
+	}
+	if bestChild == nil || bestChild.Parent != root {
+		t.Fatalf("best child: %v", bestChild)
+	}
+	logMctNodeInfo(t, root)
+}
+
+func TestMonteCarloTreeSearchContextCanceled(t *testing.T) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bestChild, err := root.MonteCarloTreeSearchContext(ctx)
+	if err != context.Canceled || bestChild != nil {
+		t.Fatalf("best child: %v, error: %v", bestChild, err)
+	}
+	if root.NumSim != 0 || root.LastChild != nil {
+		t.Fatalf("tree is changed, NumSim: %d", root.NumSim)
+	}
+	// The tree can be searched again.
+	_, err = root.Simulate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bestChild, err = root.MonteCarloTreeSearchContext(ctx)
+	if err != nil || bestChild == nil {
+		t.Fatalf("best child: %v, error: %v", bestChild, err)
+	}
+}
+
+func TestMonteCarloTreeSearchSeed(t *testing.T) {
+	settings := NewSettings()
+	settings.Seed = 42
+	settings.MctsNumSim = 300
+	var bestPos [2]board.Position
+	var children [2][]uint64
+	for i := range bestPos {
+		b, err := board.NewBoard(board.DefaultBoardSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := NewTree(rules.StandardGomoku, b, settings,
+			&goctpf.WorkerSettings{Number: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := tree.NewNode(0, board.InvalidPosition)
+		if err != nil {
+			tree.Close()
+			t.Fatal(err)
+		}
+		bestChild, err := root.MonteCarloTreeSearch()
+		tree.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root.NumSim != settings.MctsNumSim {
+			t.Errorf("root NumSim: %d, want %d", root.NumSim,
+				settings.MctsNumSim)
+		}
+		bestPos[i] = bestChild.Pos
+		for node := root.LastChild; node != nil; node = node.PrevSibling {
+			children[i] = append(children[i], uint64(node.Pos), node.NumSim,
+				node.NumWin)
+		}
+	}
+	if bestPos[0] != bestPos[1] {
+		t.Errorf("best pos: %v and %v", bestPos[0], bestPos[1])
+	}
+	if !reflect.DeepEqual(children[0], children[1]) {
+		t.Errorf("children differ:\n%v\n%v", children[0], children[1])
+	}
+}
+
+func TestMonteCarloTreeSearchParallel(t *testing.T) {
+	testMonteCarloTreeSearchParallel(t, false)
+}
+
+func TestMonteCarloTreeSearchRootParallel(t *testing.T) {
+	testMonteCarloTreeSearchParallel(t, true)
+}
+
+func testMonteCarloTreeSearchParallel(t *testing.T, isRootParallel bool) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := NewSettings()
+	settings.MctsNumSim = 1000
+	settings.IsRootParallel = isRootParallel
+	tree, err := NewTree(rules.StandardGomoku, b, settings,
+		&goctpf.WorkerSettings{Number: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	root, err := tree.NewNode(0, board.InvalidPosition)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bestChild, err := root.MonteCarloTreeSearch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bestChild == nil {
+		t.Fatal("best child is nil")
+	}
+	if root.NumSim != settings.MctsNumSim {
+		t.Errorf("root NumSim: %d, want %d", root.NumSim, settings.MctsNumSim)
+	}
+	var sum uint64
+	for node := root.LastChild; node != nil; node = node.PrevSibling {
+		sum += node.NumSim
+		for _, p := range root.unexpPos {
+			if p == node.Pos {
+				t.Errorf("%v is both expanded and unexpanded", p)
+			}
+		}
+	}
+	if sum != root.NumSim {
+		t.Errorf("sum of children NumSim: %d, root NumSim: %d", sum,
+			root.NumSim)
+	}
+	// All virtual losses should be removed.
+	var check func(node *Node)
+	check = func(node *Node) {
+		if node.numVirtualLoss != 0 {
+			t.Errorf("node %v has %d virtual losses", node.Pos,
+				node.numVirtualLoss)
+		}
+		for c := node.LastChild; c != nil; c = c.PrevSibling {
+			check(c)
+		}
+	}
+	check(root)
+}
+
+func testSimulateNTimes(t *testing.T, n int) {
+	tree, root := newTestTree(t)
+	defer tree.Close()
+	var etSum time.Duration
+	for i := 0; i < n; i++ {
+		et, err := root.Simulate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		etSum += et
+	}
+	t.Log("Total elapsed time:", etSum)
+	t.Log("Average elasped time:", float64(etSum)/float64(n))
+	logMctNodeInfo(t, root)
+}
+
+func newTestTree(tb testing.TB) (*Tree, *Node) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tree, err := NewTree(rules.StandardGomoku, b, nil, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	root, err := tree.NewNode(0, board.InvalidPosition)
+	if err != nil {
+		tree.Close()
+		tb.Fatal(err)
+	}
+	return tree, root
+}
+
+func logMctNodeInfo(tb testing.TB, mctNode *Node) {
+	tb.Logf("Node - Pos: %v, NumWin: %d, NumSim: %d, UCT: %.6f",
+		mctNode.Pos, mctNode.NumWin, mctNode.NumSim, mctNode.Uct())
+	tb.Logf("Unexpanded pos: (len = %d) %v",
+		len(mctNode.unexpPos), mctNode.unexpPos)
+	sorted := append(mctNode.unexpPos[:0:0], mctNode.unexpPos...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	tb.Log("  Sorted:", sorted)
+	tb.Log("Expanded nodes:")
+	minPos := board.MaxPosition + 1
+	maxPos := board.MinPosition - 1
+	i := 0
+	for node := mctNode.LastChild; node != nil; node = node.PrevSibling {
+		if node.Pos < minPos {
+			minPos = node.Pos
+		}
+		if node.Pos > maxPos {
+			maxPos = node.Pos
+		}
+		i++
+		tb.Logf("  %d - Pos: %v, NumWin: %d, NumSim: %d, UCT: %.6f",
+			i, node.Pos, node.NumWin, node.NumSim, node.Uct())
+	}
+	if i > 0 {
+		tb.Logf("  Pos min: %v, max: %v", minPos, maxPos)
+	} else {
+		tb.Log("  None")
+	}
+}
+
+func BenchmarkSimulate(b *testing.B) {
+	tree, root := newTestTree(b)
+	defer tree.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := root.Simulate()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMonteCarloTreeSearchTreeParallel(b *testing.B) {
+	benchmarkMonteCarloTreeSearchParallel(b, false)
+}
+
+func BenchmarkMonteCarloTreeSearchRootParallel(b *testing.B) {
+	benchmarkMonteCarloTreeSearchParallel(b, true)
+}
+
+func benchmarkMonteCarloTreeSearchParallel(b *testing.B, isRootParallel bool) {
+	settings := NewSettings()
+	settings.MctsNumSim = 1000
+	settings.IsRootParallel = isRootParallel
+	for i := 0; i < b.N; i++ {
+		bd, err := board.NewBoard(board.DefaultBoardSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+		tree, err := NewTree(rules.StandardGomoku, bd, settings, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		root, err := tree.NewNode(0, board.InvalidPosition)
+		if err == nil {
+			_, err = root.MonteCarloTreeSearch()
+		}
+		tree.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package mcts
+
+import (
+	"sync/atomic"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// Proven result of a node, for the player who placed the stone of the node,
+// assuming both players play the best among the valid positions.
+type Proof int8
+
+const (
+	NotProven Proof = iota
+	ProvenWin
+	ProvenLoss
+	ProvenDraw
+)
+
+var proofStrings = [...]string{
+	NotProven:  "Not proven",
+	ProvenWin:  "Proven win",
+	ProvenLoss: "Proven loss",
+	ProvenDraw: "Proven draw",
+}
+
+func (p Proof) String() string {
+	if p < 0 || int(p) >= len(proofStrings) {
+		return "Unknown"
+	}
+	return proofStrings[p]
+}
+
+func (mctn *Node) Proof() Proof {
+	if mctn == nil {
+		return NotProven
+	}
+	return Proof(atomic.LoadInt32(&mctn.proof))
+}
+
+// Set the proof if the node is not proven yet.
+func (mctn *Node) setProof(proof Proof) {
+	atomic.CompareAndSwapInt32(&mctn.proof, int32(NotProven), int32(proof))
+}
+
+// Return the outcome of the game from the node, which is proven.
+// Return InvalidPiece if it's not proven.
+func (mctn *Node) provenOutcome() board.Piece {
+	switch mctn.Proof() {
+	case ProvenWin:
+		return board.PieceOfStep(mctn.Step)
+	case ProvenLoss:
+		return board.PieceOfStep(mctn.Step + 1)
+	case ProvenDraw:
+		return 0
+	default:
+		return board.InvalidPiece
+	}
+}
+
+// Check the proof of mctn by its children:
+// a loss if any child is a proven win for the opponent, and if mctn is fully
+// expanded and all children are proven, a draw if any child is a draw,
+// otherwise a win.
+func (mctn *Node) checkProof() Proof {
+	mctn.mu.Lock()
+	isFullyExpanded := len(mctn.unexpPos) == 0
+	node := mctn.LastChild
+	mctn.mu.Unlock()
+	isAllProven := isFullyExpanded
+	var hasDraw bool
+	for ; node != nil; node = node.PrevSibling {
+		switch node.Proof() {
+		case ProvenWin:
+			return ProvenLoss
+		case ProvenDraw:
+			hasDraw = true
+		case NotProven:
+			isAllProven = false
+		}
+	}
+	switch {
+	case !isAllProven:
+		return NotProven
+	case hasDraw:
+		return ProvenDraw
+	default:
+		return ProvenWin
+	}
+}
+
+// Propagate the proof of mctn up to its ancestors.
+func (mctn *Node) propagateProof() {
+	if mctn.Proof() == NotProven {
+		return
+	}
+	for node := mctn.Parent; node != nil; node = node.Parent {
+		if node.Proof() != NotProven {
+			// Already proven by another search.
+			return
+		}
+		proof := node.checkProof()
+		if proof == NotProven {
+			return
+		}
+		node.setProof(proof)
+	}
+}
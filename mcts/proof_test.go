@@ -0,0 +1,98 @@
+package mcts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/donyori/goctpf"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestProvenLoss(t *testing.T) {
+	// Black to move with a four, so White's last move is a proven loss.
+	tree, root := newTestTreeWithStones(t, []string{"h8", "i8", "j8", "k8"},
+		[]string{"a1", "c1", "e1", "g1"}, "g1", false)
+	defer tree.Close()
+	startTime := time.Now()
+	best, err := root.MonteCarloTreeSearch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("Elapsed time:", time.Since(startTime))
+	if root.Proof() != ProvenLoss {
+		t.Errorf("root proof: %v, want %v", root.Proof(), ProvenLoss)
+	}
+	if best == nil || best.Proof() != ProvenWin {
+		t.Fatalf("best child: %v", best)
+	}
+	if s := best.Pos.String(); s != "G8" && s != "L8" {
+		t.Errorf("best child pos: %v, want G8 or L8", best.Pos)
+	}
+}
+
+func TestProvenWin(t *testing.T) {
+	// White to move against an open four, so Black's last move is
+	// a proven win.
+	for _, isRootParallel := range []bool{false, true} {
+		tree, root := newTestTreeWithStones(t,
+			[]string{"h8", "i8", "j8", "k8"}, []string{"a1", "c1", "e1"},
+			"k8", isRootParallel)
+		best, err := root.MonteCarloTreeSearch()
+		tree.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root.Proof() != ProvenWin {
+			t.Errorf("root-parallel: %t, root proof: %v, want %v",
+				isRootParallel, root.Proof(), ProvenWin)
+		}
+		if best == nil || best.Proof() != ProvenLoss {
+			t.Errorf("root-parallel: %t, best child: %v", isRootParallel,
+				best)
+		}
+	}
+}
+
+func TestProofString(t *testing.T) {
+	for _, p := range []Proof{NotProven, ProvenWin, ProvenLoss, ProvenDraw} {
+		if p.String() == "Unknown" {
+			t.Errorf("Proof(%d).String() = Unknown", p)
+		}
+	}
+	if s := Proof(-1).String(); s != "Unknown" {
+		t.Errorf("Proof(-1).String() = %q", s)
+	}
+}
+
+// Return a tree with the stones placed, and its root whose last stone is
+// at last. The search has a time limit of one minute, and stops early only
+// if the root is proven.
+func newTestTreeWithStones(tb testing.TB, black, white []string, last string,
+	isRootParallel bool) (*Tree, *Node) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	placeTestStones(tb, b, black, board.Black)
+	placeTestStones(tb, b, white, board.White)
+	lastPos, err := board.ParsePosition(last)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	settings := NewSettings()
+	settings.MctsTimeLimit = time.Minute
+	settings.IsRootParallel = isRootParallel
+	tree, err := NewTree(rules.StandardGomoku, b, settings,
+		&goctpf.WorkerSettings{Number: 2})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	root, err := tree.NewNode(uint(b.NumStone()), lastPos)
+	if err != nil {
+		tree.Close()
+		tb.Fatal(err)
+	}
+	return tree, root
+}
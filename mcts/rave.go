@@ -0,0 +1,75 @@
+package mcts
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// Rapid Action Value Estimation (RAVE), with all-moves-as-first (AMAF)
+// statistics: a child also counts the simulations through its parent in
+// which its player placed a stone at its position later, not just first.
+
+// Return the rate of AMAF simulations won by the player who placed the stone
+// of this node.
+func (mctn *Node) AmafWinRate() float64 {
+	if mctn == nil {
+		return 0.
+	}
+	n := atomic.LoadUint64(&mctn.NumAmafSim)
+	if n == 0 {
+		return 0.
+	}
+	return float64(atomic.LoadUint64(&mctn.NumAmafWin)) / float64(n)
+}
+
+// Return the win rate w/n blended with the AMAF win rate, by the weight of
+// sqrt(k / (3n + k)) on the AMAF one, where k is Settings.RaveEquivalence.
+// That is, the AMAF win rate dominates at first, and fades out as the node
+// is simulated more, i.e. it's half weighted when n = k.
+func (mctn *Node) raveWinRate(w, n float64) float64 {
+	k := mctn.Tree.Settings.RaveEquivalence
+	if k <= 0. || atomic.LoadUint64(&mctn.NumAmafSim) == 0 {
+		return w / n
+	}
+	beta := math.Sqrt(k / (3.*n + k))
+	return (1.-beta)*w/n + beta*mctn.AmafWinRate()
+}
+
+// Record the stones placed from mctn(exclusive) to node(inclusive),
+// in played, indexed by position, as the step of the stone.
+func (mctn *Node) recordPath(node *Node, played []uint16) {
+	for ; node != mctn && node != nil; node = node.Parent {
+		played[node.Pos] = uint16(node.Step)
+	}
+}
+
+// Update the AMAF statistics of the children of the nodes from node up to
+// mctn, with the outcome of the simulation and the stones placed in it.
+func (mctn *Node) updateAmaf(node *Node, outcome board.Piece,
+	played []uint16) {
+	for ; node != nil; node = node.Parent {
+		node.updateChildrenAmaf(outcome, played)
+		if node == mctn {
+			return
+		}
+	}
+}
+
+func (mctn *Node) updateChildrenAmaf(outcome board.Piece, played []uint16) {
+	mctn.mu.Lock()
+	child := mctn.LastChild
+	mctn.mu.Unlock()
+	for ; child != nil; child = child.PrevSibling {
+		step := uint(played[child.Pos])
+		if step <= mctn.Step || step%2 != child.Step%2 {
+			// Not placed by the player of the child after mctn.
+			continue
+		}
+		atomic.AddUint64(&child.NumAmafSim, 1)
+		if outcome == board.PieceOfStep(child.Step) {
+			atomic.AddUint64(&child.NumAmafWin, 1)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package mcts
+
+import (
+	"math"
+	"testing"
+
+	"github.com/donyori/goctpf"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestRaveStatistics(t *testing.T) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeTestStones(t, b, []string{"h8"}, board.Black)
+	h8, err := board.ParsePosition("h8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := NewSettings()
+	settings.MctsNumSim = 500
+	tree, err := NewTree(rules.StandardGomoku, b, settings,
+		&goctpf.WorkerSettings{Number: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	root, err := tree.NewNode(1, h8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = root.MonteCarloTreeSearch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hasMore bool
+	for node := root.LastChild; node != nil; node = node.PrevSibling {
+		// Each simulation through the child counts in its AMAF statistics.
+		if node.NumAmafSim < node.NumSim || node.NumAmafWin < node.NumWin {
+			t.Errorf("%v - NumWin: %d, NumSim: %d, NumAmafWin: %d, "+
+				"NumAmafSim: %d", node.Pos, node.NumWin, node.NumSim,
+				node.NumAmafWin, node.NumAmafSim)
+		}
+		if node.NumAmafSim > node.NumSim {
+			hasMore = true
+		}
+	}
+	if !hasMore {
+		t.Error("no AMAF statistics from other simulations")
+	}
+	best := root.GetBestNumSimChild()
+	w, n := float64(best.NumWin), float64(best.NumSim)
+	if r := best.raveWinRate(w, n); r == w/n && best.AmafWinRate() != w/n {
+		t.Errorf("RAVE win rate: %f, same as the win rate", r)
+	}
+	settings.RaveEquivalence = 0
+	if r := best.raveWinRate(w, n); math.Abs(r-w/n) > Epsilon {
+		t.Errorf("RAVE win rate: %f, want %f when RAVE is disabled", r, w/n)
+	}
+}
+
+func TestRaveMaxBoardCorner(t *testing.T) {
+	b, err := board.NewBoard(board.MaxBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeTestStones(t, b, []string{"y25"}, board.Black)
+	y25, err := board.ParsePosition("y25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := NewSettings()
+	settings.MctsNumSim = 50
+	if settings.RaveEquivalence <= 0. {
+		t.Fatal("RAVE is disabled by default")
+	}
+	tree, err := NewTree(rules.StandardGomoku, b, settings,
+		&goctpf.WorkerSettings{Number: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	root, err := tree.NewNode(1, y25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulations through Z26, the last position, are recorded for RAVE.
+	_, err = root.MonteCarloTreeSearch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var z26 *Node
+	for node := root.LastChild; node != nil; node = node.PrevSibling {
+		if node.Pos == board.MaxPosition {
+			z26 = node
+		}
+	}
+	if z26 == nil || z26.NumSim == 0 {
+		t.Errorf("Z26 is not searched: %v", z26)
+	}
+}
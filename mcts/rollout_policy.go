@@ -0,0 +1,160 @@
+package mcts
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/eval"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// RolloutPolicy picks the positions of the stones in rollouts.
+// It must be safe for concurrent use, as the workers of a search share it.
+type RolloutPolicy interface {
+	// Pick one of vps, the valid positions for the stone of the specified
+	// step on b. vps is not empty. b may be modified during the call, but
+	// must be restored before returning.
+	// rng is the random numbers of the calling worker.
+	Pick(rule rules.Rule, b *board.Board, step uint, vps []board.Position,
+		rng *rand.Rand) board.Position
+}
+
+// Names of the built-in rollout policies.
+const (
+	RandomRolloutPolicyName string = "random"
+	ThreatRolloutPolicyName string = "threat"
+)
+
+var (
+	rolloutPoliciesLock sync.RWMutex
+	rolloutPolicies     = map[string]RolloutPolicy{
+		RandomRolloutPolicyName: RandomRolloutPolicy{},
+		ThreatRolloutPolicyName: ThreatRolloutPolicy{},
+	}
+)
+
+// Register a rollout policy, so that it can be selected by its name in
+// Settings.RolloutPolicy. A policy of the same name is replaced.
+func RegisterRolloutPolicy(name string, policy RolloutPolicy) {
+	if policy == nil {
+		panic(fmt.Errorf("rollout policy %q is nil", name))
+	}
+	rolloutPoliciesLock.Lock()
+	defer rolloutPoliciesLock.Unlock()
+	rolloutPolicies[name] = policy
+}
+
+// Return the rollout policy registered by name.
+// An empty name is the same as RandomRolloutPolicyName.
+func GetRolloutPolicy(name string) (RolloutPolicy, error) {
+	if name == "" {
+		name = RandomRolloutPolicyName
+	}
+	rolloutPoliciesLock.RLock()
+	defer rolloutPoliciesLock.RUnlock()
+	policy, ok := rolloutPolicies[name]
+	if !ok {
+		return nil, fmt.Errorf("rollout policy %q is unknown", name)
+	}
+	return policy, nil
+}
+
+// Pick one of the valid positions randomly, with equal probability.
+type RandomRolloutPolicy struct{}
+
+func (RandomRolloutPolicy) Pick(rule rules.Rule, b *board.Board, step uint,
+	vps []board.Position, rng *rand.Rand) board.Position {
+	return vps[rng.Intn(len(vps))]
+}
+
+// Win if it can, block the opponent's win if it must, make an open four
+// if it can, block the opponent's open four if it must, otherwise pick
+// a position randomly, with the probability in proportion to its score of
+// attack plus defense by eval.ScoreMove.
+// Open fours include double fours, and are checked by the rule.
+type ThreatRolloutPolicy struct{}
+
+func (ThreatRolloutPolicy) Pick(rule rules.Rule, b *board.Board, step uint,
+	vps []board.Position, rng *rand.Rand) board.Position {
+	piece := board.PieceOfStep(step)
+	opponent := board.PieceOfStep(step + 1)
+	var blocks []board.Position
+	for _, pos := range vps {
+		b.Set(pos, piece)
+		isWin := rules.CheckOutcome(rule, b, pos) == piece
+		b.Set(pos, opponent)
+		isLoss := rules.CheckOutcome(rule, b, pos) == opponent
+		b.Set(pos, 0)
+		if isWin {
+			return pos
+		}
+		if isLoss {
+			blocks = append(blocks, pos)
+		}
+	}
+	if len(blocks) > 0 {
+		return blocks[rng.Intn(len(blocks))]
+	}
+	// Add 1 to each score, so that any position can be picked.
+	scores := make([]int, len(vps))
+	total := 0
+	var fours []board.Position
+	for i, pos := range vps {
+		own := eval.ScoreMove(rule, b, pos, piece)
+		other := eval.ScoreMove(rule, b, pos, opponent)
+		if own >= eval.FourScore && eval.MakesFour(rule, b, pos, piece) &&
+			isOpenFour(rule, b, pos, piece) {
+			fours = append(fours, pos)
+		} else if other >= eval.FourScore &&
+			eval.MakesFour(rule, b, pos, opponent) &&
+			isOpenFour(rule, b, pos, opponent) {
+			blocks = append(blocks, pos)
+		}
+		scores[i] = own + other + 1
+		total += scores[i]
+	}
+	if len(fours) > 0 {
+		return fours[rng.Intn(len(fours))]
+	}
+	if len(blocks) > 0 {
+		return blocks[rng.Intn(len(blocks))]
+	}
+	r := rng.Intn(total)
+	for i, score := range scores {
+		if r < score {
+			return vps[i]
+		}
+		r -= score
+	}
+	return vps[len(vps)-1]
+}
+
+// Return true if the stone of piece at pos, which should be empty, makes
+// two or more positions on the lines through it win by rule, i.e. an open
+// four or a double four. b is restored before returning.
+func isOpenFour(rule rules.Rule, b *board.Board, pos board.Position,
+	piece board.Piece) bool {
+	b.Set(pos, piece)
+	defer b.Set(pos, 0)
+	var n int
+	for _, dir := range board.LineDirections {
+		for k := -4; k <= 4; k++ {
+			p := b.PositionAlong(pos, dir, k)
+			if k == 0 || b.Get(p) != 0 {
+				continue
+			}
+			b.Set(p, piece)
+			isWin := rules.CheckOutcome(rule, b, p) == piece
+			b.Set(p, 0)
+			if isWin {
+				n++
+				if n >= 2 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package mcts
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+func TestThreatRolloutPolicy(t *testing.T) {
+	cases := []struct {
+		black, white []string
+		step         uint
+		want         []string
+	}{
+		// Black wins.
+		{[]string{"h8", "i8", "j8", "k8"}, []string{"h9", "i9", "j9", "k9"},
+			9, []string{"G8", "L8"}},
+		// Black blocks White's five.
+		{[]string{"h8", "i8", "a1", "a3"}, []string{"h9", "i9", "j9", "k9"},
+			9, []string{"G9", "L9"}},
+		// Black makes an open four rather than blocking White's.
+		{[]string{"h8", "i8", "j8"}, []string{"h10", "i10", "j10"},
+			7, []string{"G8", "K8"}},
+		// Black blocks White's open four.
+		{[]string{"a1", "a3", "o15"}, []string{"h9", "i9", "j9"},
+			7, []string{"G9", "K9"}},
+		// White's split three makes an open four only at J9.
+		{[]string{"a1", "a3", "o15"}, []string{"h9", "i9", "k9"},
+			7, []string{"J9", "J9"}},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for _, c := range cases {
+		b, err := board.NewBoard(board.DefaultBoardSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeTestStones(t, b, c.black, board.Black)
+		placeTestStones(t, b, c.white, board.White)
+		vps := rules.GetValidPositions(rules.StandardGomoku, b, c.step, 1)
+		for i := 0; i < 10; i++ {
+			pos := ThreatRolloutPolicy{}.Pick(rules.StandardGomoku, b, c.step,
+				vps, rng)
+			if s := pos.String(); s != c.want[0] && s != c.want[1] {
+				t.Errorf("black %v, white %v, pick %v, want one of %v",
+					c.black, c.white, pos, c.want)
+			}
+		}
+		if b.NumStone() != len(c.black)+len(c.white) {
+			t.Errorf("board is not restored, %d stones", b.NumStone())
+		}
+	}
+}
+
+func TestGetRolloutPolicy(t *testing.T) {
+	for _, name := range []string{"", RandomRolloutPolicyName,
+		ThreatRolloutPolicyName} {
+		policy, err := GetRolloutPolicy(name)
+		if err != nil || policy == nil {
+			t.Errorf("GetRolloutPolicy(%q) = %v, %v", name, policy, err)
+		}
+	}
+	_, err := GetRolloutPolicy("unknown")
+	if err == nil {
+		t.Error("no error for an unknown policy")
+	}
+	RegisterRolloutPolicy("test", RandomRolloutPolicy{})
+	policy, err := GetRolloutPolicy("test")
+	if err != nil || policy != (RandomRolloutPolicy{}) {
+		t.Errorf("registered policy: %v, %v", policy, err)
+	}
+}
+
+func placeTestStones(tb testing.TB, b *board.Board, stones []string,
+	piece board.Piece) {
+	for _, s := range stones {
+		p, err := board.ParsePosition(s)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		b.Set(p, piece)
+	}
+}
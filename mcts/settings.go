@@ -0,0 +1,42 @@
+package mcts
+
+import (
+	"math"
+	"time"
+)
+
+type Settings struct {
+	MctsTimeLimit  time.Duration `json:"mcts_time_limit,omitempty"`
+	ValidDistThold uint8         `json:"valid_dist_thold,omitempty"`
+	UctCmpThold    float64       `json:"uct_cmp_thold,omitempty"`
+	UctParamC      float64       `json:"uct_param_c,omitempty"`
+	// Number of simulations of each search. If positive, it's used instead
+	// of MctsTimeLimit, so that the search doesn't depend on the speed.
+	MctsNumSim uint64 `json:"mcts_num_sim,omitempty"`
+	// Seed of the random numbers of the search. 0 for a seed from the clock.
+	// With the same non-zero seed and MctsNumSim, and one worker,
+	// the search is reproducible. Games don't ponder with a seed set.
+	Seed int64 `json:"seed,omitempty"`
+	// If true, each worker searches an independent tree from the root,
+	// and the results are merged at the children of the root.
+	// Otherwise, the workers search the same tree with virtual losses.
+	IsRootParallel bool `json:"is_root_parallel,omitempty"`
+	// Name of the rollout policy, "random", "threat", or the name of a policy
+	// registered by RegisterRolloutPolicy.
+	RolloutPolicy string `json:"rollout_policy,omitempty"`
+	// Number of simulations of a node at which its RAVE win rate and
+	// its own win rate are equally weighted in Uct. Not positive to disable
+	// RAVE.
+	RaveEquivalence float64 `json:"rave_equivalence,omitempty"`
+}
+
+func NewSettings() *Settings {
+	return &Settings{
+		MctsTimeLimit:   time.Second * 15,
+		ValidDistThold:  1,
+		UctCmpThold:     1e-4,
+		UctParamC:       math.Sqrt2,
+		RolloutPolicy:   ThreatRolloutPolicyName,
+		RaveEquivalence: 1000,
+	}
+}
@@ -0,0 +1,19 @@
+package mcts
+
+type NodeAndUct struct {
+	Node  *Node
+	Uct   float64
+	Index int // Same as CalcUctTask.Index.
+}
+
+type CalcUctTask struct {
+	Node   *Node
+	Output chan<- *NodeAndUct
+	// Index of the node among its siblings, to keep the outputs in order.
+	Index int
+}
+
+type WaitAndCloseTask struct {
+	WaitTgt  interface{ Wait() }
+	CloseTgt interface{}
+}
@@ -0,0 +1,263 @@
+// Package mcts provides Monte Carlo tree search for gomoku games.
+package mcts
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/donyori/goctpf"
+	"github.com/donyori/goctpf/idtpf/dfw"
+	"github.com/donyori/goctpf/prefab"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+var Epsilon float64 = math.Nextafter(1., 2.) - 1.
+
+var ErrTreeClosed error = errors.New("tree is already closed")
+
+// Tree holds what the nodes of a Monte Carlo tree share: the rule, settings,
+// the board at the root, and the workers to calculate UCT values.
+type Tree struct {
+	Rule     rules.Rule
+	Settings *Settings
+	// Board at the root. Nodes build their boards from it, so it should be
+	// updated when the root changes.
+	Board *board.Board
+	// Policy of rollouts, selected by Settings.RolloutPolicy.
+	RolloutPolicy RolloutPolicy
+
+	// Random numbers of the methods other than the search.
+	// They are not locked, so don't call them during the search.
+	rng *rand.Rand
+	// Random numbers of each worker of the search. rngs[0] is rng.
+	rngs []*rand.Rand
+
+	waitAndCloseInputChan chan<- interface{}
+	waitAndCloseDoneChan  <-chan struct{}
+	calcUctInputChan      chan<- interface{}
+	calcUctDoneChan       <-chan struct{}
+}
+
+// Create a tree and start its workers. Call Close to stop the workers.
+// b is the board at the root, and is not copied.
+// If settings is nil, default settings are used.
+// If workerSettings is nil, goctpf.NewWorkerSettings() is used.
+// The search runs workerSettings.Number simulations in parallel.
+func NewTree(rule rules.Rule, b *board.Board, settings *Settings,
+	workerSettings *goctpf.WorkerSettings) (*Tree, error) {
+	if b == nil {
+		return nil, errors.New("board is nil")
+	}
+	// Check the rule first, as GetValidPositions ignores errors.
+	_, _, err := rules.IsLegal(rule, b, 1, board.GetCenterPosition(b.Size()))
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = NewSettings()
+	}
+	if workerSettings == nil {
+		workerSettings = goctpf.NewWorkerSettings()
+	}
+	rolloutPolicy, err := GetRolloutPolicy(settings.RolloutPolicy)
+	if err != nil {
+		return nil, err
+	}
+	seed := settings.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t := &Tree{
+		Rule:          rule,
+		Settings:      settings,
+		Board:         b,
+		RolloutPolicy: rolloutPolicy,
+		rng:           rand.New(rand.NewSource(seed)),
+	}
+	numWorker := workerSettings.Number
+	if numWorker < 1 {
+		numWorker = 1
+	}
+	t.rngs = make([]*rand.Rand, numWorker)
+	t.rngs[0] = t.rng
+	for i := 1; i < numWorker; i++ {
+		t.rngs[i] = rand.New(rand.NewSource(t.rng.Int63()))
+	}
+	wacic := make(chan interface{}, 1)
+	cuic := make(chan interface{}, 1)
+	t.waitAndCloseInputChan = wacic
+	t.waitAndCloseDoneChan = dfw.StartEx(prefab.QueueTaskManagerMaker,
+		t.waitAndCloseHandler, nil, nil, goctpf.WorkerSettings{Number: 3},
+		wacic, nil)
+	t.calcUctInputChan = cuic
+	t.calcUctDoneChan = dfw.StartEx(prefab.StackTaskManagerMaker,
+		t.calcUctHandler, nil, nil, *workerSettings, cuic, nil)
+	return t, nil
+}
+
+func (t *Tree) IsClosed() bool {
+	return t == nil || t.calcUctInputChan == nil
+}
+
+// Stop the workers of the tree.
+func (t *Tree) Close() {
+	if t == nil {
+		return
+	}
+	if t.calcUctInputChan != nil {
+		close(t.calcUctInputChan)
+		t.calcUctInputChan = nil
+	}
+	if t.waitAndCloseInputChan != nil {
+		close(t.waitAndCloseInputChan)
+		t.waitAndCloseInputChan = nil
+	}
+	if t.calcUctDoneChan != nil {
+		<-t.calcUctDoneChan
+		t.calcUctDoneChan = nil
+	}
+	if t.waitAndCloseDoneChan != nil {
+		<-t.waitAndCloseDoneChan
+		t.waitAndCloseDoneChan = nil
+	}
+}
+
+// Create a node as a root, where step stones have been placed and
+// the last one is at pos. The board of the node is t.Board.
+func (t *Tree) NewNode(step uint, pos board.Position) (*Node, error) {
+	if t.IsClosed() {
+		return nil, ErrTreeClosed
+	}
+	node := &Node{
+		Tree: t,
+		Step: step,
+		Pos:  pos,
+	}
+	if step > 0 {
+		piece := t.CheckOutcome(t.Board, pos)
+		switch piece {
+		case 0, board.Both:
+			node.unexpPos = t.GetValidPositions(t.Board, step+1, true)
+			if len(node.unexpPos) == 0 {
+				node.proof = int32(ProvenDraw)
+			}
+		case board.Black, board.White:
+			node.unexpPos = nil
+			node.proof = int32(ProvenWin)
+		default:
+			return nil, fmt.Errorf("cannot check outcome on position %v", pos)
+		}
+	} else {
+		node.unexpPos = t.GetValidPositions(t.Board, 1, true)
+	}
+	return node, nil
+}
+
+// Return valid positions for the stone of the specified step on the board.
+// See rules.GetValidPositions for details.
+func (t *Tree) GetValidPositions(b *board.Board, step uint, doesShuffle bool) (
+	vps []board.Position) {
+	if doesShuffle {
+		return t.getValidPositions(b, step, t.rng)
+	}
+	return t.getValidPositions(b, step, nil)
+}
+
+// Same as GetValidPositions, but shuffled by rng, or not if rng is nil.
+func (t *Tree) getValidPositions(b *board.Board, step uint, rng *rand.Rand) (
+	vps []board.Position) {
+	vps = rules.GetValidPositions(t.Rule, b, step,
+		int(t.Settings.ValidDistThold))
+	if rng != nil {
+		rng.Shuffle(len(vps), func(i int, j int) {
+			vps[i], vps[j] = vps[j], vps[i]
+		})
+	}
+	return
+}
+
+// Check whether the stone at pos wins.
+// Return the winner, or 0 if not win.
+func (t *Tree) CheckOutcome(b *board.Board, pos board.Position) board.Piece {
+	return rules.CheckOutcome(t.Rule, b, pos)
+}
+
+func (t *Tree) SubmitWaitAndCloseTask(task *WaitAndCloseTask) error {
+	if t.IsClosed() {
+		return ErrTreeClosed
+	}
+	if task == nil {
+		return errors.New("task is nil")
+	}
+	if task.WaitTgt == nil {
+		return errors.New("WaitAndCloseTask.WaitTgt is nil")
+	}
+	if task.CloseTgt == nil {
+		return errors.New("WaitAndCloseTask.CloseTgt is nil")
+	}
+	tt := reflect.TypeOf(task.CloseTgt)
+	if tt.Kind() != reflect.Chan || tt.ChanDir()&reflect.SendDir == 0 {
+		return errors.New("WaitAndCloseTask.CloseTgt is not send chan")
+	}
+	t.waitAndCloseInputChan <- task
+	return nil
+}
+
+func (t *Tree) SubmitCalcUctTask(task interface{}) error {
+	if t.IsClosed() {
+		return ErrTreeClosed
+	}
+	if task == nil {
+		return errors.New("task is nil")
+	}
+	var calcUctTask *CalcUctTask
+	var ok bool
+	switch task.(type) {
+	case *goctpf.TaskGroupMember:
+		tk := task.(*goctpf.TaskGroupMember).Task
+		calcUctTask, ok = tk.(*CalcUctTask)
+	case *CalcUctTask:
+		calcUctTask = task.(*CalcUctTask)
+		ok = true
+	}
+	if !ok {
+		return fmt.Errorf(
+			"task is neither a CalcUctTask nor a TaskGroupMember with a CalcUctTask, task type: %T",
+			task)
+	}
+	if calcUctTask == nil {
+		return errors.New("CalcUctTask is nil")
+	}
+	if calcUctTask.Node == nil {
+		return errors.New("CalcUctTask.Node is nil")
+	}
+	if calcUctTask.Output == nil {
+		return errors.New("CalcUctTask.Output is nil")
+	}
+	t.calcUctInputChan <- task
+	return nil
+}
+
+func (t *Tree) waitAndCloseHandler(workerNo int, task interface{},
+	errBuf *[]error) (newTasks []interface{}, doesExit bool) {
+	// Always return nil, false. So just use "return".
+	tk := task.(*WaitAndCloseTask)
+	tk.WaitTgt.Wait()
+	reflect.ValueOf(tk.CloseTgt).Close()
+	return
+}
+
+func (t *Tree) calcUctHandler(workerNo int, task interface{},
+	errBuf *[]error) (newTasks []interface{}, doesExit bool) {
+	// Always return nil, false. So just use "return".
+	tk := task.(*goctpf.TaskGroupMember).Task.(*CalcUctTask)
+	uct := tk.Node.Uct()
+	tk.Output <- &NodeAndUct{Node: tk.Node, Uct: uct, Index: tk.Index}
+	return
+}
@@ -0,0 +1,404 @@
+// Package piskvork implements the Piskvork (Gomocup) protocol for the AI.
+package piskvork
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/alphabeta"
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+	"github.com/donyori/ucashw_gt_gomoku/solver"
+)
+
+// Piskvork (Gomocup) protocol, see https://plastovicka.github.io/protocl2en.htm
+
+const piskvorkAbout string = `name="ucashw_gt_gomoku", author="Yuan GAO"`
+
+// Bits of the value of "INFO rule".
+const (
+	piskvorkExactlyFive int = 1
+	piskvorkRenju       int = 4
+)
+
+// Time reserved for the communication and the tree building on each turn.
+const (
+	piskvorkMinTimeMargin time.Duration = time.Millisecond * 50
+	piskvorkMinTimeLimit  time.Duration = time.Millisecond * 10
+)
+
+// Assumed number of the remaining moves of the engine, to share the time left
+// of the match.
+const piskvorkNumRemainingMoves int64 = 20
+
+// Fraction of the time limit of each turn for the VCF and VCT search.
+const piskvorkSolveTimeDivisor time.Duration = 10
+
+type piskvorkEngine struct {
+	settings game.Settings
+	ai       game.AiSettings
+	game     *game.Game
+
+	scanner *bufio.Scanner
+	w       io.Writer
+
+	// Time limit of the search if the manager doesn't tell the timeouts.
+	defaultTimeLimit time.Duration
+	// In milliseconds. Negative for unknown.
+	// timeoutMatch is 0 for no limit of the match. timeLeft is counted down
+	// by the time of each turn until the manager tells it again.
+	timeoutTurn, timeoutMatch, timeLeft int64
+}
+
+// Speak Piskvork protocol on r and w, until "END" is received or r is closed.
+// settings is not modified.
+func Run(r io.Reader, w io.Writer, settings *game.Settings) error {
+	if r == nil {
+		return errors.New("r is nil")
+	}
+	if w == nil {
+		return errors.New("w is nil")
+	}
+	if settings == nil {
+		settings = game.NewSettings()
+	}
+	e := &piskvorkEngine{
+		settings:     *settings,
+		scanner:      bufio.NewScanner(r),
+		w:            w,
+		timeoutTurn:  -1,
+		timeoutMatch: -1,
+		timeLeft:     -1,
+	}
+	if settings.Ai != nil {
+		e.ai = *settings.Ai
+	} else {
+		e.ai = *game.NewSettings().Ai
+	}
+	e.settings.Ai = &e.ai
+	if e.ai.AlphaBeta != nil {
+		ab := *e.ai.AlphaBeta
+		e.ai.AlphaBeta = &ab
+	} else {
+		e.ai.AlphaBeta = alphabeta.NewSettings()
+	}
+	if e.ai.Solver != nil {
+		s := *e.ai.Solver
+		e.ai.Solver = &s
+	} else {
+		e.ai.Solver = solver.NewSettings()
+	}
+	e.defaultTimeLimit = e.ai.MctsTimeLimit
+	if e.ai.Engine == game.AlphaBetaEngine {
+		e.defaultTimeLimit = e.ai.AlphaBeta.TimeLimit
+	}
+	if e.settings.Rule.Opening() != rules.NoOpening {
+		// Opening protocols are not supported.
+		e.settings.Rule = rules.StandardGomoku
+	}
+	if e.settings.Worker == nil {
+		e.settings.Worker = game.NewSettings().Worker
+	}
+	defer e.endGame()
+	for e.scanner.Scan() {
+		line := strings.TrimSpace(e.scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		cmd := strings.ToUpper(fields[0])
+		args := fields[1:]
+		if cmd == "END" {
+			return nil
+		}
+		err := e.handle(cmd, args)
+		if err != nil {
+			return err
+		}
+	}
+	return e.scanner.Err()
+}
+
+// Return an error only if the communication fails.
+func (e *piskvorkEngine) handle(cmd string, args []string) error {
+	switch cmd {
+	case "START":
+		if len(args) != 1 {
+			return e.reply("ERROR", "board size is required")
+		}
+		size, err := strconv.Atoi(args[0])
+		if err != nil {
+			return e.reply("ERROR", "board size is invalid:", args[0])
+		}
+		e.settings.BoardSize = size
+		err = e.newGame()
+		if err != nil {
+			return e.reply("ERROR", err)
+		}
+		return e.reply("OK")
+	case "RESTART":
+		err := e.newGame()
+		if err != nil {
+			return e.reply("ERROR", err)
+		}
+		return e.reply("OK")
+	case "BEGIN":
+		if e.game == nil {
+			return e.reply("ERROR", "game is not started")
+		}
+		if e.game.Step() > 0 {
+			return e.reply("ERROR", "game is already begun")
+		}
+		e.game.AiPiece = board.Black
+		return e.placeByAi()
+	case "TURN":
+		if e.game == nil {
+			return e.reply("ERROR", "game is not started")
+		}
+		if len(args) != 1 {
+			return e.reply("ERROR", "position is required")
+		}
+		pos, err := e.parsePosition(args[0])
+		if err != nil {
+			return e.reply("ERROR", err)
+		}
+		if e.game.Step() == 0 {
+			e.game.AiPiece = board.White
+		}
+		err = e.placeByOpponent(pos)
+		if err != nil {
+			return e.reply("ERROR", err)
+		}
+		return e.placeByAi()
+	case "BOARD":
+		return e.board()
+	case "INFO":
+		if len(args) >= 2 {
+			e.info(strings.ToLower(args[0]), args[1])
+		}
+		return nil
+	case "ABOUT":
+		return e.reply(piskvorkAbout)
+	default:
+		return e.reply("UNKNOWN", "command", cmd, "is unknown")
+	}
+}
+
+// Read the stones until "DONE", replay them on a new game, and place a stone.
+func (e *piskvorkEngine) board() error {
+	var own, opp []board.Position
+	var lineErr error
+	for e.scanner.Scan() {
+		line := strings.TrimSpace(e.scanner.Text())
+		if strings.ToUpper(line) == "DONE" {
+			break
+		}
+		if line == "" || lineErr != nil {
+			continue
+		}
+		i := strings.LastIndexByte(line, ',')
+		if i < 0 {
+			lineErr = fmt.Errorf("line %q is invalid", line)
+			continue
+		}
+		pos, err := e.parsePosition(line[:i])
+		if err != nil {
+			lineErr = err
+			continue
+		}
+		switch strings.TrimSpace(line[i+1:]) {
+		case "1":
+			own = append(own, pos)
+		case "2":
+			opp = append(opp, pos)
+		default:
+			lineErr = fmt.Errorf("line %q is not supported", line)
+		}
+	}
+	if err := e.scanner.Err(); err != nil {
+		return err
+	}
+	if lineErr != nil {
+		return e.reply("ERROR", lineErr)
+	}
+	var black, white []board.Position
+	switch len(opp) - len(own) {
+	case 0:
+		e.ai.AiPiece = board.Black
+		black, white = own, opp
+	case 1:
+		e.ai.AiPiece = board.White
+		black, white = opp, own
+	default:
+		return e.reply("ERROR", "numbers of stones are unbalanced")
+	}
+	err := e.newGame()
+	if err != nil {
+		return e.reply("ERROR", err)
+	}
+	for i := range black {
+		err = e.placeByOpponent(black[i])
+		if err == nil && i < len(white) {
+			err = e.placeByOpponent(white[i])
+		}
+		if err != nil {
+			return e.reply("ERROR", err)
+		}
+	}
+	return e.placeByAi()
+}
+
+func (e *piskvorkEngine) info(key, value string) {
+	switch key {
+	case "timeout_turn", "timeout_match", "time_left":
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return
+		}
+		switch key {
+		case "timeout_turn":
+			e.timeoutTurn = ms
+		case "timeout_match":
+			e.timeoutMatch = ms
+			if ms > 0 && e.timeLeft < 0 {
+				// The whole match is left until the manager tells.
+				e.timeLeft = ms
+			}
+		default:
+			e.timeLeft = ms
+		}
+	case "rule":
+		bits, err := strconv.Atoi(value)
+		if err != nil {
+			return
+		}
+		var rule rules.Rule
+		if bits&piskvorkRenju != 0 {
+			rule = rules.Renju
+		} else if bits&piskvorkExactlyFive != 0 {
+			rule = rules.StandardGomoku
+		} else {
+			rule = rules.FreestyleGomoku
+		}
+		if rule == e.settings.Rule {
+			return
+		}
+		e.settings.Rule = rule
+		// Apply to the current game if it's not begun.
+		if e.game != nil && e.game.Step() == 0 {
+			err = e.newGame()
+			if err != nil {
+				e.reply("ERROR", err)
+			}
+		}
+	}
+}
+
+func (e *piskvorkEngine) newGame() error {
+	e.endGame()
+	g, err := game.NewGame(&e.settings)
+	if err != nil {
+		return err
+	}
+	e.game = g
+	return nil
+}
+
+func (e *piskvorkEngine) endGame() {
+	if e.game != nil {
+		e.game.TearDown()
+		e.game = nil
+	}
+}
+
+func (e *piskvorkEngine) placeByOpponent(pos board.Position) error {
+	if e.game.IsTerminal() {
+		return errors.New("game is over")
+	}
+	if !pos.IsOnBoard(e.game.BoardSize()) {
+		return board.NewPositionOutOfRangeError(pos.X(), pos.Y(), e.game.BoardSize())
+	}
+	return e.game.PlaceByUser(pos)
+}
+
+func (e *piskvorkEngine) placeByAi() error {
+	if e.game.IsTerminal() {
+		return e.reply("ERROR", "game is over")
+	}
+	limit := e.timeLimit()
+	e.ai.Solver.TimeLimit = limit / piskvorkSolveTimeDivisor
+	e.ai.MctsTimeLimit = limit - e.ai.Solver.TimeLimit
+	e.ai.AlphaBeta.TimeLimit = e.ai.MctsTimeLimit
+	// Also stop the search by MctsNumSim at the end of the turn.
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+	start := time.Now()
+	pos, err := e.game.PlaceByAiContext(ctx)
+	if e.timeLeft >= 0 {
+		e.timeLeft -= int64(time.Since(start) / time.Millisecond)
+		if e.timeLeft < 0 {
+			e.timeLeft = 0
+		}
+	}
+	if err != nil {
+		return e.reply("ERROR", err)
+	}
+	return e.reply(fmt.Sprintf("%d,%d", pos.X(), pos.Y()))
+}
+
+// Return the time limit of the search on this turn.
+func (e *piskvorkEngine) timeLimit() time.Duration {
+	limit := e.defaultTimeLimit
+	if e.timeoutTurn >= 0 {
+		limit = time.Duration(e.timeoutTurn) * time.Millisecond
+	}
+	if e.timeoutMatch != 0 && e.timeLeft >= 0 {
+		share := time.Duration(e.timeLeft/piskvorkNumRemainingMoves) *
+			time.Millisecond
+		if share < limit {
+			limit = share
+		}
+	}
+	margin := limit / 10
+	if margin < piskvorkMinTimeMargin {
+		margin = piskvorkMinTimeMargin
+	}
+	limit -= margin
+	if limit < piskvorkMinTimeLimit {
+		limit = piskvorkMinTimeLimit
+	}
+	return limit
+}
+
+// Parse "x,y" with 0-based coordinates.
+func (e *piskvorkEngine) parsePosition(s string) (board.Position, error) {
+	xy := strings.Split(s, ",")
+	if len(xy) != 2 {
+		return board.InvalidPosition, board.NewUnknownPositionError(s)
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(xy[0]))
+	if err != nil {
+		return board.InvalidPosition, board.NewUnknownPositionError(s)
+	}
+	y, err := strconv.Atoi(strings.TrimSpace(xy[1]))
+	if err != nil {
+		return board.InvalidPosition, board.NewUnknownPositionError(s)
+	}
+	size := e.settings.BoardSize
+	if x < 0 || x >= size || y < 0 || y >= size {
+		return board.InvalidPosition, board.NewPositionOutOfRangeError(x, y, size)
+	}
+	return board.GetPosition(x, y)
+}
+
+func (e *piskvorkEngine) reply(a ...interface{}) error {
+	_, err := fmt.Fprintln(e.w, a...)
+	return err
+}
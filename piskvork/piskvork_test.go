@@ -0,0 +1,145 @@
+package piskvork
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/book"
+	"github.com/donyori/ucashw_gt_gomoku/game"
+)
+
+func TestRun(t *testing.T) {
+	settings := game.NewSettings()
+	settings.Ai.MctsTimeLimit = time.Second * 15
+	input := strings.Join([]string{
+		"ABOUT",
+		"START 15",
+		"INFO timeout_turn 300",
+		"INFO rule 1",
+		"BEGIN",
+		"RESTART",
+		"TURN 7,7",
+		"BOARD",
+		"7,7,2",
+		"8,8,1",
+		"7,8,2",
+		"DONE",
+		"TURN 20,20",
+		"FOO",
+		"END",
+		"ABOUT",
+	}, "\r\n")
+	var output bytes.Buffer
+	err := Run(strings.NewReader(input), &output, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("\n" + output.String())
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("number of output lines: %d, want 8", len(lines))
+	}
+	if lines[0] != piskvorkAbout {
+		t.Errorf("ABOUT: %q", lines[0])
+	}
+	for _, i := range []int{1, 3} {
+		if lines[i] != "OK" {
+			t.Errorf("line %d: %q, want OK", i, lines[i])
+		}
+	}
+	// The first stone is at the center.
+	if lines[2] != "7,7" {
+		t.Errorf("BEGIN: %q, want 7,7", lines[2])
+	}
+	occupied := map[int][]string{
+		4: {"7,7"},
+		5: {"7,7", "8,8", "7,8"},
+	}
+	e := &piskvorkEngine{settings: *settings}
+	for i, stones := range occupied {
+		if _, err := e.parsePosition(lines[i]); err != nil {
+			t.Errorf("line %d: %v", i, err)
+		}
+		for _, s := range stones {
+			if lines[i] == s {
+				t.Errorf("line %d: %s is occupied", i, s)
+			}
+		}
+	}
+	if !strings.HasPrefix(lines[6], "ERROR") {
+		t.Errorf("TURN out of range: %q", lines[6])
+	}
+	if !strings.HasPrefix(lines[7], "UNKNOWN") {
+		t.Errorf("unknown command: %q", lines[7])
+	}
+	if settings.Ai.MctsTimeLimit != time.Second*15 {
+		t.Error("settings are modified")
+	}
+}
+
+func TestTimeLimit(t *testing.T) {
+	cases := []struct {
+		infos []string
+		want  time.Duration
+	}{
+		{nil, time.Second*10 - time.Second},
+		{[]string{"timeout_turn 5000"}, time.Millisecond * 4500},
+		// The share of the match is 20000 / 20 ms.
+		{[]string{"timeout_turn 5000", "timeout_match 20000"},
+			time.Millisecond * 900},
+		{[]string{"timeout_turn 5000", "timeout_match 100000",
+			"time_left 20000"}, time.Millisecond * 900},
+		{[]string{"timeout_turn 5000", "time_left 20000"},
+			time.Millisecond * 900},
+		// No limit of the match.
+		{[]string{"timeout_turn 5000", "timeout_match 0", "time_left 20000"},
+			time.Millisecond * 4500},
+		{[]string{"timeout_turn 5000", "timeout_match 20000", "time_left 0"},
+			piskvorkMinTimeLimit},
+	}
+	for _, c := range cases {
+		e := &piskvorkEngine{
+			defaultTimeLimit: time.Second * 10,
+			timeoutTurn:      -1,
+			timeoutMatch:     -1,
+			timeLeft:         -1,
+		}
+		for _, info := range c.infos {
+			kv := strings.Fields(info)
+			e.info(kv[0], kv[1])
+		}
+		if limit := e.timeLimit(); limit != c.want {
+			t.Errorf("%v: time limit: %v, want %v", c.infos, limit, c.want)
+		}
+	}
+}
+
+func TestRunNumSimDeadline(t *testing.T) {
+	settings := game.NewSettings()
+	settings.Ai.MctsNumSim = 1 << 30
+	settings.Ai.Book = &book.Settings{}
+	input := strings.Join([]string{
+		"START 15",
+		"INFO timeout_turn 300",
+		"BOARD",
+		"7,7,2",
+		"8,8,1",
+		"DONE",
+		"END",
+	}, "\r\n")
+	var output bytes.Buffer
+	start := time.Now()
+	err := Run(strings.NewReader(input), &output, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second*5 {
+		t.Errorf("elapsed: %v, want about 300ms", elapsed)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 || strings.HasPrefix(lines[1], "ERROR") {
+		t.Errorf("output: %q", output.String())
+	}
+}
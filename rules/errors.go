@@ -0,0 +1,26 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+type IllegalPositionError struct {
+	pos  board.Position
+	hint string
+}
+
+var ErrUnknownRule error = errors.New("rule is unknown")
+
+func NewIllegalPositionError(pos board.Position, hint string) error {
+	return &IllegalPositionError{pos: pos, hint: hint}
+}
+
+func (ipe *IllegalPositionError) Error() string {
+	if ipe.hint == "" {
+		return fmt.Sprintf("position %v is illegal", ipe.pos)
+	}
+	return fmt.Sprintf("position %v is illegal: %s", ipe.pos, ipe.hint)
+}
@@ -0,0 +1,68 @@
+package rules
+
+import "github.com/donyori/ucashw_gt_gomoku/board"
+
+// Check whether the stone at pos wins.
+// Return the winner, or 0 if not win.
+func CheckOutcome(rule Rule, b *board.Board, pos board.Position) board.Piece {
+	piece := b.Get(pos)
+	switch piece {
+	case 0, board.Both:
+		return 0
+	case board.Black, board.White:
+		// Do nothing here.
+	default:
+		return board.InvalidPiece
+	}
+	winCond := rule.WinCondition(piece)
+	for _, dir := range board.LineDirections {
+		n := b.LineLength(pos, dir)
+		if n == 5 || (n > 5 && winCond == FiveOrMore) {
+			return piece
+		}
+	}
+	return 0
+}
+
+// Return valid positions for the stone of the specified step on the board,
+// in ascending order.
+// Valid positions are the legal positions within distThold from any stone.
+// Treat distThold <= 0 as no additional limit.
+// Return nil if step is zero.
+func GetValidPositions(rule Rule, b *board.Board, step uint, distThold int) (
+	vps []board.Position) {
+	if step == 0 || b == nil {
+		return nil
+	}
+	if step == 1 {
+		// First step can be at any legal position.
+		// If the center(e.g. "H8") is legal, only place at the center.
+		center := board.GetCenterPosition(b.Size())
+		isLegal, _, err := IsLegal(rule, b, 1, center)
+		if isLegal && err == nil {
+			return []board.Position{center}
+		}
+	}
+	if step == 3 && rule == GomokuPro && distThold < 2 {
+		// Special case.
+		distThold = 2
+	}
+	var candidates []board.Position
+	if step == 1 || distThold <= 0 || b.NumStone() == 0 {
+		candidates = board.GetAllPositions(b.Size())
+	} else {
+		candidates = b.GetNearbyEmptyPositions(distThold)
+	}
+	vps = make([]board.Position, 0, len(candidates))
+	for _, p := range candidates {
+		isLegal, _, err := IsLegal(rule, b, step, p)
+		if isLegal && err == nil {
+			vps = append(vps, p)
+		}
+	}
+	if len(vps) != cap(vps) {
+		// Shrink the array:
+		vps = vps[:len(vps):len(vps)]
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+func TestCheckOutcomeWinCondition(t *testing.T) {
+	five := []string{"d8", "e8", "f8", "g8", "h8"}
+	six := []string{"c8", "d8", "e8", "f8", "g8", "h8"}
+	cases := []struct {
+		rule   Rule
+		stones []string
+		piece  board.Piece
+		want   board.Piece
+	}{
+		{StandardGomoku, five, board.Black, board.Black},
+		{StandardGomoku, six, board.Black, 0},
+		{StandardGomoku, six, board.White, 0},
+		{FreestyleGomoku, five, board.White, board.White},
+		{FreestyleGomoku, six, board.Black, board.Black},
+		{GomokuPro, six, board.White, 0},
+		{Renju, five, board.Black, board.Black},
+		{Renju, six, board.Black, 0},
+		{Renju, six, board.White, board.White},
+	}
+	for _, c := range cases {
+		b, err := board.NewBoard(board.DefaultBoardSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeTestStones(t, b, c.stones, c.piece)
+		for _, s := range c.stones {
+			pos, err := board.ParsePosition(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if outcome := CheckOutcome(c.rule, b, pos); outcome != c.want {
+				t.Errorf("rule: %v, stones: %v (%v), CheckOutcome(%v) = %v, want %v",
+					c.rule, c.stones, c.piece, pos, outcome, c.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+// Forbidden moves of Black under Renju rule.
+
+type ForbiddenKind int8
+
+const (
+	NotForbidden ForbiddenKind = iota
+	DoubleThree
+	DoubleFour
+	Overline
+)
+
+// Limit the depth of recursive checks on the points that turn a three into a
+// straight four, which are required to be non-forbidden themselves.
+const maxForbiddenCheckDepth int = 8
+
+func (fk ForbiddenKind) String() string {
+	switch fk {
+	case NotForbidden:
+		return "NotForbidden"
+	case DoubleThree:
+		return "DoubleThree"
+	case DoubleFour:
+		return "DoubleFour"
+	case Overline:
+		return "Overline"
+	default:
+		return "Unknown"
+	}
+}
+
+func (fk ForbiddenKind) Hint() string {
+	switch fk {
+	case DoubleThree:
+		return "Black's double-three is forbidden."
+	case DoubleFour:
+		return "Black's double-four is forbidden."
+	case Overline:
+		return "Black's overline is forbidden."
+	default:
+		return ""
+	}
+}
+
+// Check whether placing a black stone at pos is forbidden.
+// pos should be empty on the board.
+// A move that makes exactly five is never forbidden.
+// The board is modified temporarily during the check and restored after,
+// so it must not be accessed concurrently.
+func CheckForbidden(b *board.Board, pos board.Position) (
+	ForbiddenKind, error) {
+	if b == nil {
+		return NotForbidden, errors.New("board is nil")
+	}
+	if !pos.IsOnBoard(b.Size()) {
+		return NotForbidden, board.NewPositionOutOfRangeError(pos.X(), pos.Y(),
+			b.Size())
+	}
+	if b.Get(pos) != 0 {
+		return NotForbidden, fmt.Errorf("position %v is occupied", pos)
+	}
+	return checkForbidden(b, pos, 0), nil
+}
+
+func checkForbidden(b *board.Board, pos board.Position, depth int) ForbiddenKind {
+	b.Set(pos, board.Black)
+	defer b.Set(pos, 0)
+	var isOverline bool
+	for _, dir := range board.LineDirections {
+		n := b.LineLength(pos, dir)
+		if n == 5 {
+			return NotForbidden
+		} else if n > 5 {
+			isOverline = true
+		}
+	}
+	if isOverline {
+		return Overline
+	}
+	var numFour, numThree int
+	for _, dir := range board.LineDirections {
+		n := countFours(b, pos, dir)
+		numFour += n
+		if n == 0 && hasThree(b, pos, dir, depth) {
+			numThree++
+		}
+	}
+	if numFour >= 2 {
+		return DoubleFour
+	}
+	if numThree >= 2 {
+		return DoubleThree
+	}
+	return NotForbidden
+}
+
+// Count the distinct fours through pos along dir.
+// A four is a group of four black stones which becomes exactly five by
+// adding one stone. A straight four has two such points but counts once.
+func countFours(b *board.Board, pos board.Position,
+	dir board.Direction) int {
+	var stoneSets [2]uint32
+	var n int
+	for k := -4; k <= 4; k++ {
+		if k == 0 || pieceAlong(b, pos, dir, k) != 0 {
+			continue
+		}
+		q := b.PositionAlong(pos, dir, k)
+		b.Set(q, board.Black)
+		lo, hi := blackRun(b, pos, dir)
+		b.Set(q, 0)
+		if hi-lo+1 != 5 || k < lo || k > hi {
+			continue
+		}
+		var set uint32
+		for i := lo; i <= hi; i++ {
+			if i != k {
+				set |= 1 << uint(i+5)
+			}
+		}
+		isNew := true
+		for i := 0; i < n && i < len(stoneSets); i++ {
+			if stoneSets[i] == set {
+				isNew = false
+				break
+			}
+		}
+		if isNew {
+			if n < len(stoneSets) {
+				stoneSets[n] = set
+			}
+			n++
+		}
+	}
+	return n
+}
+
+// Report whether there is a three through pos along dir, i.e. a point that
+// turns it into a straight four and is not forbidden itself.
+func hasThree(b *board.Board, pos board.Position, dir board.Direction,
+	depth int) bool {
+	for k := -3; k <= 3; k++ {
+		if k == 0 || pieceAlong(b, pos, dir, k) != 0 {
+			continue
+		}
+		q := b.PositionAlong(pos, dir, k)
+		b.Set(q, board.Black)
+		lo, hi := blackRun(b, pos, dir)
+		// Both ends must become exactly five, i.e. empty and not followed by
+		// another black stone.
+		isStraightFour := hi-lo+1 == 4 && k >= lo && k <= hi &&
+			pieceAlong(b, pos, dir, lo-1) == 0 &&
+			pieceAlong(b, pos, dir, hi+1) == 0 &&
+			pieceAlong(b, pos, dir, lo-2) != board.Black &&
+			pieceAlong(b, pos, dir, hi+2) != board.Black
+		b.Set(q, 0)
+		if !isStraightFour {
+			continue
+		}
+		if depth >= maxForbiddenCheckDepth ||
+			checkForbidden(b, q, depth+1) == NotForbidden {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the offsets (relative to pos, along dir) of both ends of the
+// contiguous black stones containing pos.
+func blackRun(b *board.Board, pos board.Position, dir board.Direction) (
+	lo, hi int) {
+	for pieceAlong(b, pos, dir, lo-1) == board.Black {
+		lo--
+	}
+	for pieceAlong(b, pos, dir, hi+1) == board.Black {
+		hi++
+	}
+	return
+}
+
+// Return InvalidPiece if the position is outside the board.
+func pieceAlong(b *board.Board, pos board.Position, dir board.Direction,
+	k int) board.Piece {
+	return b.Get(b.PositionAlong(pos, dir, k))
+}
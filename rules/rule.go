@@ -0,0 +1,191 @@
+// Package rules provides the rules of gomoku games, including legality of
+// moves, forbidden moves under Renju and outcomes.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+type Rule int8
+
+const (
+	StandardGomoku Rule = iota + 1
+	GomokuPro
+	Renju
+	FreestyleGomoku
+	GomokuSwap
+	GomokuSwap2
+)
+
+var ruleStrings = [...]string{
+	"Unknown",
+	"StandardGomoku",
+	"Gomoku-Pro",
+	"Renju",
+	"FreestyleGomoku",
+	"Gomoku-Swap",
+	"Gomoku-Swap2",
+}
+
+type WinCondition int8
+
+const (
+	ExactlyFive WinCondition = iota + 1 // Overlines do not win.
+	FiveOrMore
+)
+
+type Opening int8
+
+const (
+	NoOpening Opening = iota
+	// The first player places three stones(two black and one white),
+	// then the second player chooses color.
+	SwapOpening
+	// As SwapOpening, but the second player can also place two more
+	// stones(one white and one black) and let the first player choose color.
+	Swap2Opening
+)
+
+// Number of stones placed by the first player under swap openings.
+const NumSwapOpeningStones uint = 3
+
+// Number of stones placed by the second player when choosing PlaceTwoMore.
+const NumSwap2ExtraStones uint = 2
+
+func ParseRule(s string) Rule {
+	for i := range ruleStrings {
+		if strings.EqualFold(s, ruleStrings[i]) {
+			return Rule(i)
+		}
+	}
+	return 0 // Stands for "Unknown".
+}
+
+func (r Rule) String() string {
+	if r < StandardGomoku || int(r) >= len(ruleStrings) {
+		return ruleStrings[0]
+	}
+	return ruleStrings[r]
+}
+
+func (r Rule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rule) UnmarshalText(text []byte) error {
+	*r = ParseRule(string(text))
+	return nil
+}
+
+// Return the win condition for the player of the specified piece.
+// Return 0 for unknown rule or invalid piece.
+func (r Rule) WinCondition(piece board.Piece) WinCondition {
+	if piece != board.Black && piece != board.White {
+		return 0
+	}
+	switch r {
+	case StandardGomoku, GomokuPro, GomokuSwap, GomokuSwap2:
+		return ExactlyFive
+	case Renju:
+		if piece == board.Black {
+			return ExactlyFive
+		}
+		return FiveOrMore
+	case FreestyleGomoku:
+		return FiveOrMore
+	default:
+		return 0
+	}
+}
+
+func (r Rule) Opening() Opening {
+	switch r {
+	case GomokuSwap:
+		return SwapOpening
+	case GomokuSwap2:
+		return Swap2Opening
+	default:
+		return NoOpening
+	}
+}
+
+// b is the board before placing the stone.
+func IsLegal(rule Rule, b *board.Board, step uint, pos board.Position) (
+	isLegal bool, hint string, err error) {
+	if b == nil {
+		return false, "", errors.New("board is nil")
+	}
+	if step == 0 {
+		return false, "", errors.New("step is zero")
+	}
+	switch rule {
+	case StandardGomoku, FreestyleGomoku, GomokuSwap, GomokuSwap2:
+		return isLegalStdGomoku(b, step, pos)
+	case GomokuPro:
+		return isLegalGomokuPro(b, step, pos)
+	case Renju:
+		return isLegalRenju(b, step, pos)
+	default:
+		return false, "", ErrUnknownRule
+	}
+}
+
+func isLegalStdGomoku(b *board.Board, step uint, pos board.Position) (
+	isLegal bool, hint string, err error) {
+	if !pos.IsOnBoard(b.Size()) {
+		return false, "Position is outside the board.", nil
+	}
+	if b.Get(pos) != 0 {
+		return false, "Position is already occupied.", nil
+	}
+	return true, "", nil
+}
+
+func isLegalGomokuPro(b *board.Board, step uint, pos board.Position) (
+	isLegal bool, hint string, err error) {
+	if step != 1 && step != 3 {
+		return isLegalStdGomoku(b, step, pos)
+	}
+	boardSize := b.Size()
+	x, y := pos.XOffset(boardSize), pos.YOffset(boardSize)
+	if step == 1 {
+		if x != 0 || y != 0 {
+			return false, fmt.Sprintf("First step must be at %v.",
+				board.GetCenterPosition(boardSize)), nil
+		}
+		return true, "", nil
+	} else { // step == 3
+		ia, h, e := isLegalStdGomoku(b, step, pos)
+		if !ia {
+			return ia, h, e
+		}
+		if x >= -2 && x <= 2 && y >= -2 && y <= 2 {
+			return false, "Third step must be outside the central 5×5 area.", nil
+		}
+		return true, "", nil
+	}
+}
+
+func isLegalRenju(b *board.Board, step uint, pos board.Position) (
+	isLegal bool, hint string, err error) {
+	ia, h, e := isLegalStdGomoku(b, step, pos)
+	if !ia || e != nil {
+		return ia, h, e
+	}
+	if step%2 == 0 {
+		// White has no forbidden move.
+		return true, "", nil
+	}
+	fk, err := CheckForbidden(b, pos)
+	if err != nil {
+		return false, "", err
+	}
+	if fk != NotForbidden {
+		return false, fk.Hint(), nil
+	}
+	return true, "", nil
+}
@@ -0,0 +1,101 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+)
+
+func TestIsLegalRenju(t *testing.T) {
+	cases := []struct {
+		name   string
+		blacks []string
+		whites []string
+		pos    string
+		want   ForbiddenKind
+	}{
+		{"single three", []string{"h8", "i8"}, nil, "j8", NotForbidden},
+		{"double three", []string{"h8", "i8", "j9", "j10"}, nil, "j8", DoubleThree},
+		{"double three with gap", []string{"g8", "i8", "j9", "j11"}, nil, "j8",
+			DoubleThree},
+		{"blocked three", []string{"h8", "i8", "j9", "j10"}, []string{"g8"}, "j8",
+			NotForbidden},
+		{"double four", []string{"h8", "i8", "j8", "k9", "k10", "k11"}, nil, "k8",
+			DoubleFour},
+		{"double four in a line", []string{"f8", "h8", "j8", "l8"}, nil, "i8",
+			DoubleFour},
+		{"four with an overline point", []string{"d8", "f8", "g8", "i8"}, nil,
+			"h8", NotForbidden},
+		{"four three", []string{"h8", "i8", "j8", "k9", "k10"},
+			[]string{"g8"}, "k8", NotForbidden},
+		{"overline", []string{"c8", "d8", "e8", "g8", "h8"}, nil, "f8", Overline},
+		{"five with double four", []string{"h8", "i8", "j8", "k8", "l9", "l10",
+			"l11"}, nil, "l8", NotForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := board.NewBoard(board.DefaultBoardSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			placeTestStones(t, b, c.blacks, board.Black)
+			placeTestStones(t, b, c.whites, board.White)
+			pos, err := board.ParsePosition(c.pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fk, err := CheckForbidden(b, pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fk != c.want {
+				t.Errorf("CheckForbidden(%v) = %v, want %v", pos, fk, c.want)
+			}
+			isLegal, hint, err := IsLegal(Renju, b, 1, pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isLegal != (c.want == NotForbidden) {
+				t.Errorf("IsLegal(Renju, %v) = %t, hint: %q", pos, isLegal, hint)
+			}
+			// White is never forbidden.
+			isLegal, _, err = IsLegal(Renju, b, 2, pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !isLegal {
+				t.Errorf("IsLegal(Renju, %v) for White = false", pos)
+			}
+		})
+	}
+}
+
+func TestIsLegalOccupied(t *testing.T) {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	center := board.GetCenterPosition(board.DefaultBoardSize)
+	b.Set(center, board.Black)
+	for _, rule := range []Rule{StandardGomoku, Renju} {
+		isLegal, hint, err := IsLegal(rule, b, 2, center)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isLegal {
+			t.Errorf("IsLegal(%v) on an occupied position = true", rule)
+		}
+		t.Log(hint)
+	}
+}
+
+func placeTestStones(tb testing.TB, b *board.Board, stones []string,
+	piece board.Piece) {
+	for _, s := range stones {
+		p, err := board.ParsePosition(s)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		b.Set(p, piece)
+	}
+}
@@ -0,0 +1,25 @@
+package solver
+
+import "time"
+
+type Settings struct {
+	// Maximum numbers of the attacker's moves of VCF and VCT.
+	// Not positive to disable the search.
+	VcfDepth int `json:"vcf_depth,omitempty"`
+	VctDepth int `json:"vct_depth,omitempty"`
+	// Maximum time of Solve. Not positive for no limit.
+	TimeLimit time.Duration `json:"time_limit,omitempty"`
+	// Maximum number of nodes searched by Solve. 0 for no limit.
+	// Unlike TimeLimit, it doesn't depend on the speed of the machine,
+	// so the results are reproducible.
+	MaxNode uint64 `json:"max_node,omitempty"`
+}
+
+func NewSettings() *Settings {
+	return &Settings{
+		VcfDepth:  10,
+		VctDepth:  3,
+		TimeLimit: time.Second,
+		MaxNode:   200000,
+	}
+}
@@ -0,0 +1,374 @@
+// Package solver searches forced wins of gomoku games by
+// Victory by Continuous Fours (VCF) and Victory by Continuous Threats (VCT).
+//
+// The player to move is the attacker. In a VCF, each move of the attacker
+// makes a four, so the defender has to block it. In a VCT, the attacker may
+// also make a three, i.e. a move after which the attacker could make
+// an open four or a double four, and the defender may block it on its lines
+// or make a four. A winning line is returned only if the attacker wins
+// against all these replies.
+package solver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// Maximum distance from the stones to the moves of the attacker.
+const moveDistThold int = 2
+
+// Number of nodes between two checks of the deadline and the context.
+const checkInterval uint64 = 256
+
+var errAborted = errors.New("search is aborted")
+
+// Search a VCF of the player to place the stone of step on b, in at most
+// depth moves of the player. b is not modified.
+// Return the winning line, which starts with the move of the player,
+// alternates with the forced replies of the opponent, and ends with
+// the five, or nil if not found.
+// If ctx is done before the search completes, ctx.Err() is returned.
+func SolveVcf(ctx context.Context, rule rules.Rule, b *board.Board,
+	step uint, depth int) ([]board.Position, error) {
+	s, err := newSolver(ctx, rule, b, step, depth)
+	if err != nil {
+		return nil, err
+	}
+	return s.result(s.vcf(step, depth))
+}
+
+// Same as SolveVcf, but search a VCT in at most depth threats,
+// each of which may be followed by a VCF in at most vcfDepth moves.
+func SolveVct(ctx context.Context, rule rules.Rule, b *board.Board,
+	step uint, depth int, vcfDepth int) ([]board.Position, error) {
+	s, err := newSolver(ctx, rule, b, step, vcfDepth)
+	if err != nil {
+		return nil, err
+	}
+	return s.result(s.vct(step, depth))
+}
+
+// Search a VCF, then a VCT, with the depths in settings, until
+// Settings.TimeLimit, Settings.MaxNode or ctx is done. See SolveVcf for
+// details. Return nil and no error if a limit is reached.
+// If settings is nil, default settings are used.
+func Solve(ctx context.Context, rule rules.Rule, b *board.Board, step uint,
+	settings *Settings) ([]board.Position, error) {
+	if settings == nil {
+		settings = NewSettings()
+	}
+	s, err := newSolver(ctx, rule, b, step, settings.VcfDepth)
+	if err != nil {
+		return nil, err
+	}
+	if settings.TimeLimit > 0 {
+		s.deadline = time.Now().Add(settings.TimeLimit)
+	}
+	s.maxNode = settings.MaxNode
+	var line []board.Position
+	if settings.VcfDepth > 0 {
+		line, err = s.result(s.vcf(step, settings.VcfDepth))
+		if line != nil || err != nil {
+			return line, err
+		}
+	}
+	if settings.VctDepth > 0 {
+		return s.result(s.vct(step, settings.VctDepth))
+	}
+	return nil, nil
+}
+
+type solver struct {
+	ctx      context.Context
+	rule     rules.Rule
+	b        *board.Board
+	vcfDepth int
+	deadline time.Time
+	maxNode  uint64 // 0 for no limit.
+	numNode  uint64
+}
+
+func newSolver(ctx context.Context, rule rules.Rule, b *board.Board,
+	step uint, vcfDepth int) (*solver, error) {
+	if b == nil {
+		return nil, errors.New("board is nil")
+	}
+	if step == 0 {
+		return nil, errors.New("step is 0")
+	}
+	return &solver{
+		ctx:      ctx,
+		rule:     rule,
+		b:        b.Copy(),
+		vcfDepth: vcfDepth,
+	}, nil
+}
+
+// Convert errAborted to ctx.Err(), or nil if ctx is not done.
+func (s *solver) result(line []board.Position, err error) (
+	[]board.Position, error) {
+	if err == errAborted {
+		return nil, s.ctx.Err()
+	}
+	return line, err
+}
+
+// Search a VCF of the player to place the stone of step.
+func (s *solver) vcf(step uint, depth int) ([]board.Position, error) {
+	attacker := board.PieceOfStep(step)
+	defender := board.PieceOfStep(step + 1)
+	if wins := s.fives(attacker); len(wins) > 0 {
+		return wins[:1], nil
+	}
+	if depth <= 0 {
+		return nil, nil
+	}
+	blocks := s.fives(defender)
+	if len(blocks) > 1 {
+		return nil, nil
+	}
+	for _, m := range s.moves(step, blocks) {
+		if err := s.checkAborted(); err != nil {
+			return nil, err
+		}
+		s.b.Set(m, attacker)
+		threats := s.fivesThrough(m, attacker)
+		var line []board.Position
+		var err error
+		if len(threats) > 0 {
+			line, err = s.afterFour(step, m, threats, func() (
+				[]board.Position, error) {
+				return s.vcf(step+2, depth-1)
+			})
+		}
+		s.b.Set(m, 0)
+		if line != nil || err != nil {
+			return line, err
+		}
+	}
+	return nil, nil
+}
+
+// Search a VCT of the player to place the stone of step.
+func (s *solver) vct(step uint, depth int) ([]board.Position, error) {
+	attacker := board.PieceOfStep(step)
+	defender := board.PieceOfStep(step + 1)
+	if wins := s.fives(attacker); len(wins) > 0 {
+		return wins[:1], nil
+	}
+	if depth <= 0 {
+		return nil, nil
+	}
+	line, err := s.vcf(step, s.vcfDepth)
+	if line != nil || err != nil {
+		return line, err
+	}
+	blocks := s.fives(defender)
+	if len(blocks) > 1 {
+		return nil, nil
+	}
+	for _, m := range s.moves(step, blocks) {
+		if err = s.checkAborted(); err != nil {
+			return nil, err
+		}
+		s.b.Set(m, attacker)
+		if threats := s.fivesThrough(m, attacker); len(threats) > 0 {
+			line, err = s.afterFour(step, m, threats, func() (
+				[]board.Position, error) {
+				return s.vct(step+2, depth-1)
+			})
+		} else if s.isThree(m, step) {
+			line, err = s.afterThree(step, depth, m)
+		}
+		s.b.Set(m, 0)
+		if line != nil || err != nil {
+			return line, err
+		}
+	}
+	return nil, nil
+}
+
+// The attacker has made a four at m, which makes fives at threats.
+// Let the defender block it, and continue by next.
+func (s *solver) afterFour(step uint, m board.Position,
+	threats []board.Position, next func() ([]board.Position, error)) (
+	[]board.Position, error) {
+	if len(threats) > 1 {
+		// The defender cannot block both.
+		return []board.Position{m, threats[0], threats[1]}, nil
+	}
+	d := threats[0]
+	s.b.Set(d, board.PieceOfStep(step+1))
+	defer s.b.Set(d, 0)
+	sub, err := next()
+	if sub == nil || err != nil {
+		return nil, err
+	}
+	return append([]board.Position{m, d}, sub...), nil
+}
+
+// The attacker has made a three at m. Try all the defender's replies on
+// the lines through m and the defender's fours, and return the line against
+// the reply lasting longest if the attacker wins against all of them.
+func (s *solver) afterThree(step uint, depth int, m board.Position) (
+	[]board.Position, error) {
+	defender := board.PieceOfStep(step + 1)
+	var line []board.Position
+	for _, d := range s.defenses(m, step+1) {
+		if err := s.checkAborted(); err != nil {
+			return nil, err
+		}
+		s.b.Set(d, defender)
+		var sub []board.Position
+		var err error
+		if rules.CheckOutcome(s.rule, s.b, d) != defender {
+			sub, err = s.vct(step+2, depth-1)
+		}
+		s.b.Set(d, 0)
+		if sub == nil || err != nil {
+			return nil, err
+		}
+		if line == nil || len(sub)+2 > len(line) {
+			line = append([]board.Position{m, d}, sub...)
+		}
+	}
+	return line, nil
+}
+
+// Return the legal positions for the stone of step within moveDistThold from
+// any stone, or the ones in blocks if not empty.
+func (s *solver) moves(step uint, blocks []board.Position) []board.Position {
+	candidates := blocks
+	if len(candidates) == 0 {
+		candidates = s.b.GetNearbyEmptyPositions(moveDistThold)
+	}
+	ms := make([]board.Position, 0, len(candidates))
+	for _, p := range candidates {
+		isLegal, _, err := rules.IsLegal(s.rule, s.b, step, p)
+		if isLegal && err == nil {
+			ms = append(ms, p)
+		}
+	}
+	return ms
+}
+
+// Return the defender's replies to the three at m: the legal positions on
+// the lines through m within 4, and the positions where the defender makes
+// a four, for the stone of step.
+func (s *solver) defenses(m board.Position, step uint) []board.Position {
+	defender := board.PieceOfStep(step)
+	isAdded := make(map[board.Position]bool)
+	var ds []board.Position
+	add := func(p board.Position) {
+		if isAdded[p] {
+			return
+		}
+		isAdded[p] = true
+		isLegal, _, err := rules.IsLegal(s.rule, s.b, step, p)
+		if isLegal && err == nil {
+			ds = append(ds, p)
+		}
+	}
+	for _, p := range s.lineEmpties(m) {
+		add(p)
+	}
+	for _, p := range s.b.GetNearbyEmptyPositions(moveDistThold) {
+		if isAdded[p] {
+			continue
+		}
+		s.b.Set(p, defender)
+		isFour := len(s.fivesThrough(p, defender)) > 0
+		s.b.Set(p, 0)
+		if isFour {
+			add(p)
+		}
+	}
+	return ds
+}
+
+// Return true if the attacker, who placed the stone at m of step, can make
+// an open four or a double four through m by the next move.
+func (s *solver) isThree(m board.Position, step uint) bool {
+	attacker := s.b.Get(m)
+	for _, p := range s.lineEmpties(m) {
+		isLegal, _, err := rules.IsLegal(s.rule, s.b, step+2, p)
+		if !isLegal || err != nil {
+			continue
+		}
+		s.b.Set(p, attacker)
+		n := len(s.fivesThrough(p, attacker))
+		s.b.Set(p, 0)
+		if n > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the positions where piece makes five.
+func (s *solver) fives(piece board.Piece) []board.Position {
+	var ps []board.Position
+	for _, p := range s.b.GetNearbyEmptyPositions(1) {
+		if s.isFive(p, piece) {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// Return the positions on the lines through pos where piece makes five.
+func (s *solver) fivesThrough(pos board.Position, piece board.Piece) (
+	ps []board.Position) {
+	for _, p := range s.lineEmpties(pos) {
+		if s.isFive(p, piece) {
+			ps = append(ps, p)
+		}
+	}
+	return
+}
+
+func (s *solver) isFive(pos board.Position, piece board.Piece) bool {
+	s.b.Set(pos, piece)
+	defer s.b.Set(pos, 0)
+	return rules.CheckOutcome(s.rule, s.b, pos) == piece
+}
+
+// Return the empty positions on the lines through pos within 4.
+func (s *solver) lineEmpties(pos board.Position) []board.Position {
+	ps := make([]board.Position, 0, len(board.LineDirections)*8)
+	for _, dir := range board.LineDirections {
+		for k := -4; k <= 4; k++ {
+			if k == 0 {
+				continue
+			}
+			p := s.b.PositionAlong(pos, dir, k)
+			if s.b.Get(p) == 0 {
+				ps = append(ps, p)
+			}
+		}
+	}
+	return ps
+}
+
+func (s *solver) checkAborted() error {
+	s.numNode++
+	if s.maxNode > 0 && s.numNode > s.maxNode {
+		return errAborted
+	}
+	if s.numNode%checkInterval != 0 {
+		return nil
+	}
+	select {
+	case <-s.ctx.Done():
+		return errAborted
+	default:
+	}
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		return errAborted
+	}
+	return nil
+}
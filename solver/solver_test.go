@@ -0,0 +1,141 @@
+package solver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/donyori/ucashw_gt_gomoku/board"
+	"github.com/donyori/ucashw_gt_gomoku/rules"
+)
+
+// Stones of White far from the others, to make Black the next to move.
+var farWhites = []string{"A1", "O1", "A15", "O15"}
+
+func TestSolveVcf(t *testing.T) {
+	// K8 makes a double four.
+	b := newTestBoard(t, []string{"H8", "I8", "J8", "K9", "K10", "K11"},
+		append([]string{"G8", "K12"}, farWhites[:2]...))
+	line, err := SolveVcf(context.Background(), rules.StandardGomoku, b, 11, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("VCF:", line)
+	if len(line) != 3 || line[0].String() != "K8" {
+		t.Fatalf("VCF: %v, want K8 first in 3 moves", line)
+	}
+	checkWinningLine(t, b, 11, line)
+}
+
+func TestSolveVct(t *testing.T) {
+	// J8 makes a double three.
+	b := newTestBoard(t, []string{"G8", "H8", "J10", "J11"}, farWhites)
+	line, err := SolveVcf(context.Background(), rules.StandardGomoku, b, 9, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != nil {
+		t.Errorf("VCF: %v, want nil", line)
+	}
+	line, err = SolveVct(context.Background(), rules.StandardGomoku, b, 9, 2,
+		5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("VCT:", line)
+	if line == nil {
+		t.Fatal("VCT is not found")
+	}
+	checkWinningLine(t, b, 9, line)
+}
+
+func TestSolveMaxNode(t *testing.T) {
+	// The VCT of TestSolveVct.
+	b := newTestBoard(t, []string{"G8", "H8", "J10", "J11"}, farWhites)
+	settings := &Settings{VctDepth: 2, VcfDepth: 5, MaxNode: 5}
+	line, err := Solve(context.Background(), rules.StandardGomoku, b, 9,
+		settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != nil {
+		t.Errorf("line in %d nodes: %v, want nil", settings.MaxNode, line)
+	}
+	settings.MaxNode = 0
+	line, err = Solve(context.Background(), rules.StandardGomoku, b, 9,
+		settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line == nil {
+		t.Error("VCT is not found without the node limit")
+	}
+}
+
+func TestSolveMaxNodeReproducible(t *testing.T) {
+	b := newTestBoard(t, []string{"G8", "H8", "J10", "J11"}, farWhites)
+	for _, maxNode := range []uint64{5, 20, 50, 200, 1000} {
+		settings := &Settings{VctDepth: 2, VcfDepth: 5, MaxNode: maxNode,
+			TimeLimit: time.Hour}
+		var first []board.Position
+		for i := 0; i < 3; i++ {
+			line, err := Solve(context.Background(), rules.StandardGomoku, b,
+				9, settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i == 0 {
+				first = line
+			} else if !reflect.DeepEqual(line, first) {
+				t.Errorf("%d nodes: line %v, then %v", maxNode, first, line)
+			}
+		}
+	}
+}
+
+func TestSolveNoWin(t *testing.T) {
+	b := newTestBoard(t, []string{"H8", "I9"}, []string{"H9", "I8"})
+	line, err := Solve(context.Background(), rules.StandardGomoku, b, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != nil {
+		t.Errorf("line: %v, want nil", line)
+	}
+}
+
+// Check that line alternates the players from step, and the last move of
+// line makes five.
+func checkWinningLine(tb testing.TB, b *board.Board, step uint,
+	line []board.Position) {
+	b = b.Copy()
+	for i, p := range line {
+		if b.Get(p) != 0 {
+			tb.Fatalf("move %d at %v is not empty", i, p)
+		}
+		b.Set(p, board.PieceOfStep(step+uint(i)))
+	}
+	last := line[len(line)-1]
+	if rules.CheckOutcome(rules.StandardGomoku, b, last) !=
+		board.PieceOfStep(step) {
+		tb.Errorf("the last move %v doesn't win", last)
+	}
+}
+
+func newTestBoard(tb testing.TB, black, white []string) *board.Board {
+	b, err := board.NewBoard(board.DefaultBoardSize)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for i, stones := range [...][]string{black, white} {
+		for _, s := range stones {
+			p, err := board.ParsePosition(s)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			b.Set(p, board.Black+board.Piece(i))
+		}
+	}
+	return b
+}